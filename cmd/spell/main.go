@@ -7,6 +7,8 @@ import (
 	"log/slog"
 	"os"
 	"runtime/debug"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
@@ -58,6 +60,86 @@ func main() {
 				Name:    "db-parameter",
 				EnvVars: []string{"CONN_STRING_PARAMETER"},
 			},
+			&cli.IntFlag{
+				Name:    "checker-pool-size",
+				Usage:   "The number of concurrent spellchecks per language",
+				EnvVars: []string{"CHECKER_POOL_SIZE"},
+				Value:   1,
+			},
+			&cli.IntFlag{
+				Name:    "max-suggestions",
+				Usage:   "The maximum number of suggestions per misspelled word, 0 is unlimited",
+				EnvVars: []string{"MAX_SUGGESTIONS"},
+				Value:   5,
+			},
+			&cli.IntFlag{
+				Name:    "max-hunspell-suggest",
+				Usage:   "The maximum number of hunspell suggestions to use per misspelled word, 0 is unlimited",
+				EnvVars: []string{"MAX_HUNSPELL_SUGGEST"},
+			},
+			&cli.IntFlag{
+				Name:    "suggestion-cache-size",
+				Usage:   "The number of misspelled words to cache suggestions for, 0 disables the cache",
+				EnvVars: []string{"SUGGESTION_CACHE_SIZE"},
+			},
+			&cli.StringFlag{
+				Name:    "dictionary-dir",
+				Usage:   "Directory with dictionaries that add to or replace the bundled ones",
+				EnvVars: []string{"DICTIONARY_DIR"},
+			},
+			&cli.StringFlag{
+				Name:    "seed-dir",
+				Usage:   "Directory with YAML files of entries to write at startup",
+				EnvVars: []string{"SEED_DIR"},
+			},
+			&cli.Float64Flag{
+				Name:    "text-requests-per-second",
+				Usage:   "Text checks allowed per second and client, 0 is unlimited",
+				EnvVars: []string{"TEXT_REQUESTS_PER_SECOND"},
+			},
+			&cli.Float64Flag{
+				Name:    "text-words-per-second",
+				Usage:   "Words allowed to be checked per second and client, 0 is unlimited",
+				EnvVars: []string{"TEXT_WORDS_PER_SECOND"},
+			},
+			&cli.IntFlag{
+				Name:    "max-text-bytes",
+				Usage:   "The maximum total size of the texts in a check, 0 is unlimited",
+				EnvVars: []string{"MAX_TEXT_BYTES"},
+				Value:   1 << 20,
+			},
+			&cli.IntFlag{
+				Name:    "max-text-items",
+				Usage:   "The maximum number of texts in a check, 0 is unlimited",
+				EnvVars: []string{"MAX_TEXT_ITEMS"},
+				Value:   1000,
+			},
+			&cli.BoolFlag{
+				Name:    "record-spelling-gaps",
+				Usage:   "Count misspelled words that have no suggestions",
+				EnvVars: []string{"RECORD_SPELLING_GAPS"},
+			},
+			&cli.DurationFlag{
+				Name:    "slow-query-threshold",
+				Usage:   "Log database queries that take longer than this, 0 disables the logging",
+				EnvVars: []string{"SLOW_QUERY_THRESHOLD"},
+				Value:   500 * time.Millisecond,
+			},
+			&cli.StringFlag{
+				Name:    "webhook-url",
+				Usage:   "URL to post entry update notifications to",
+				EnvVars: []string{"WEBHOOK_URL"},
+			},
+			&cli.BoolFlag{
+				Name:    "sentence-bounded-phrases",
+				Usage:   "Don't match custom entry phrases across sentence boundaries",
+				EnvVars: []string{"SENTENCE_BOUNDED_PHRASES"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "word-internal-runes",
+				Usage:   "Runes that should be treated as part of a word for a language, f.ex. \"sv-se=-\"",
+				EnvVars: []string{"WORD_INTERNAL_RUNES"},
+			},
 		},
 	}
 
@@ -84,6 +166,20 @@ func runSpell(c *cli.Context) error {
 		profileAddr     = c.String("profile-addr")
 		paramSourceName = c.String("parameter-source")
 		logLevel        = c.String("log-level")
+		checkerPoolSize = c.Int("checker-pool-size")
+		maxSuggestions  = c.Int("max-suggestions")
+		maxHunspell     = c.Int("max-hunspell-suggest")
+		cacheSize       = c.Int("suggestion-cache-size")
+		dictionaryDir   = c.String("dictionary-dir")
+		seedDir         = c.String("seed-dir")
+		webhookURL      = c.String("webhook-url")
+		requestsPerSec  = c.Float64("text-requests-per-second")
+		wordsPerSec     = c.Float64("text-words-per-second")
+		maxTextBytes    = c.Int("max-text-bytes")
+		maxTextItems    = c.Int("max-text-items")
+		sentenceBounded = c.Bool("sentence-bounded-phrases")
+		slowQuery       = c.Duration("slow-query-threshold")
+		recordGaps      = c.Bool("record-spelling-gaps")
 	)
 
 	logger := elephantine.SetUpLogger(logLevel, os.Stdout)
@@ -99,6 +195,11 @@ func runSpell(c *cli.Context) error {
 		}
 	}()
 
+	segmentation, err := parseSegmentation(c.StringSlice("word-internal-runes"))
+	if err != nil {
+		return err
+	}
+
 	paramSource, err := elephantine.GetParameterSource(paramSourceName)
 	if err != nil {
 		return fmt.Errorf("get parameter source: %w", err)
@@ -132,12 +233,27 @@ func runSpell(c *cli.Context) error {
 	}
 
 	app, err := internal.NewApplication(c.Context, internal.Parameters{
-		Addr:           addr,
-		ProfileAddr:    profileAddr,
-		Logger:         logger,
-		Database:       dbpool,
-		AuthInfoParser: auth.AuthParser,
-		Registerer:     prometheus.DefaultRegisterer,
+		Addr:                   addr,
+		ProfileAddr:            profileAddr,
+		Logger:                 logger,
+		Database:               dbpool,
+		AuthInfoParser:         auth.AuthParser,
+		Registerer:             prometheus.DefaultRegisterer,
+		Segmentation:           segmentation,
+		CheckerPoolSize:        checkerPoolSize,
+		MaxSuggestions:         maxSuggestions,
+		MaxHunspellSuggest:     maxHunspell,
+		DictionaryDir:          dictionaryDir,
+		SeedDir:                seedDir,
+		WebhookURL:             webhookURL,
+		TextRequestsPerSecond:  requestsPerSec,
+		TextWordsPerSecond:     wordsPerSec,
+		MaxTextBytes:           maxTextBytes,
+		MaxTextItems:           maxTextItems,
+		SentenceBoundedPhrases: sentenceBounded,
+		SuggestionCacheSize:    cacheSize,
+		SlowQueryThreshold:     slowQuery,
+		RecordSpellingGaps:     recordGaps,
 	})
 	if err != nil {
 		return fmt.Errorf("create application: %w", err)
@@ -150,3 +266,28 @@ func runSpell(c *cli.Context) error {
 
 	return nil
 }
+
+// parseSegmentation parses "language=runes" pairs into a segmentation
+// configuration per language. The languages are normalized like the
+// languages of requests, so "sv_SE" configures "sv-se". Languages without a
+// dictionary are rejected when the application is created.
+func parseSegmentation(values []string) (map[string]internal.Segmentation, error) {
+	res := make(map[string]internal.Segmentation, len(values))
+
+	for _, v := range values {
+		lang, runes, ok := strings.Cut(v, "=")
+
+		lang = internal.NormalizeLanguage(lang)
+
+		if !ok || lang == "" || runes == "" {
+			return nil, fmt.Errorf(
+				"invalid word internal runes %q, expected language=runes", v)
+		}
+
+		seg := res[lang]
+		seg.WordInternal = append(seg.WordInternal, []rune(runes)...)
+		res[lang] = seg
+	}
+
+	return res, nil
+}
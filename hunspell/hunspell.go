@@ -8,17 +8,32 @@ package hunspell
 import "C"
 
 import (
-	"runtime"
+	"errors"
+	"fmt"
+	"os"
 	"sync"
 	"unsafe"
 )
 
+// Checker wraps a hunspell handle. The handle isn't freed by the garbage
+// collector, Close must be called when the checker is no longer used.
 type Checker struct {
 	m      sync.Mutex
 	handle *C.Hunhandle
 }
 
 func NewChecker(affixPath string, dictPath string) (*Checker, error) {
+	// Hunspell only prints a warning and loads an empty dictionary if the
+	// files are missing, so we check that they're readable first.
+	for _, path := range []string{affixPath, dictPath} {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open dictionary file: %w", err)
+		}
+
+		_ = f.Close()
+	}
+
 	c := Checker{}
 
 	cAffPath := C.CString(affixPath)
@@ -28,28 +43,60 @@ func NewChecker(affixPath string, dictPath string) (*Checker, error) {
 	defer C.free(unsafe.Pointer(cDictPath))
 
 	c.handle = C.Hunspell_create(cAffPath, cDictPath)
+	if c.handle == nil {
+		return nil, fmt.Errorf(
+			"failed to create hunspell handle for %q and %q",
+			affixPath, dictPath)
+	}
 
-	runtime.SetFinalizer(&c, func(c *Checker) {
-		C.Hunspell_destroy(c.handle)
+	return &c, nil
+}
 
-		c.handle = nil
-	})
+// Close destroys the hunspell handle. It's safe to call Close more than once,
+// and a closed checker will reject all words and return no suggestions or
+// stems.
+func (c *Checker) Close() error {
+	c.m.Lock()
+	defer c.m.Unlock()
 
-	return &c, nil
+	if c.handle == nil {
+		return nil
+	}
+
+	C.Hunspell_destroy(c.handle)
+
+	c.handle = nil
+
+	return nil
+}
+
+// Encoding returns the character encoding of the loaded dictionary, as
+// declared by the SET option in the affix file.
+func (c *Checker) Encoding() string {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.handle == nil {
+		return ""
+	}
+
+	return C.GoString(C.Hunspell_get_dic_encoding(c.handle))
 }
 
 func (c *Checker) Suggest(word string) []string {
 	cWord := C.CString(word)
 	defer C.free(unsafe.Pointer(cWord))
 
-	var (
-		cArray **C.char
-		length C.int
-	)
+	var cArray **C.char
 
 	c.m.Lock()
-	length = C.Hunspell_suggest(c.handle, &cArray, cWord)
-	c.m.Unlock()
+	defer c.m.Unlock()
+
+	if c.handle == nil {
+		return nil
+	}
+
+	length := C.Hunspell_suggest(c.handle, &cArray, cWord)
 
 	defer C.Hunspell_free_list(c.handle, &cArray, length)
 
@@ -63,19 +110,79 @@ func (c *Checker) Add(word string) bool {
 	defer C.free(unsafe.Pointer(cWord))
 
 	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.handle == nil {
+		return false
+	}
+
 	r := C.Hunspell_add(c.handle, cWord)
-	c.m.Unlock()
 
 	return int(r) == 0
 }
 
+// AddWithAffix adds a word that uses the same affix rules as the example
+// word, so that the inflected forms of the word are accepted as well.
+func (c *Checker) AddWithAffix(word string, example string) bool {
+	cWord := C.CString(word)
+	defer C.free(unsafe.Pointer(cWord))
+
+	cExample := C.CString(example)
+	defer C.free(unsafe.Pointer(cExample))
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.handle == nil {
+		return false
+	}
+
+	r := C.Hunspell_add_with_affix(c.handle, cWord, cExample)
+
+	return int(r) == 0
+}
+
+// AddDictionary loads an additional dictionary that uses the same affix file,
+// f.ex. a glossary of domain specific words.
+func (c *Checker) AddDictionary(dictPath string) error {
+	// Hunspell silently ignores dictionaries that can't be read.
+	f, err := os.Open(dictPath)
+	if err != nil {
+		return fmt.Errorf("open dictionary file: %w", err)
+	}
+
+	_ = f.Close()
+
+	cDictPath := C.CString(dictPath)
+	defer C.free(unsafe.Pointer(cDictPath))
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.handle == nil {
+		return errors.New("the checker has been closed")
+	}
+
+	r := C.Hunspell_add_dic(c.handle, cDictPath)
+	if int(r) != 0 {
+		return fmt.Errorf("failed to add dictionary %q", dictPath)
+	}
+
+	return nil
+}
+
 func (c *Checker) Remove(word string) bool {
 	cWord := C.CString(word)
 	defer C.free(unsafe.Pointer(cWord))
 
 	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.handle == nil {
+		return false
+	}
+
 	r := C.Hunspell_remove(c.handle, cWord)
-	c.m.Unlock()
 
 	return int(r) == 0
 }
@@ -84,14 +191,16 @@ func (c *Checker) Stem(word string) []string {
 	cWord := C.CString(word)
 	defer C.free(unsafe.Pointer(cWord))
 
-	var (
-		carray **C.char
-		length C.int
-	)
+	var carray **C.char
 
 	c.m.Lock()
-	length = C.Hunspell_stem(c.handle, &carray, cWord)
-	c.m.Unlock()
+	defer c.m.Unlock()
+
+	if c.handle == nil {
+		return nil
+	}
+
+	length := C.Hunspell_stem(c.handle, &carray, cWord)
 
 	defer C.Hunspell_free_list(c.handle, &carray, length)
 
@@ -100,17 +209,97 @@ func (c *Checker) Stem(word string) []string {
 	return words
 }
 
+// Analyze returns the morphological analysis of a word, f.ex. " st:skola
+// fl:D" for "skolorna".
+func (c *Checker) Analyze(word string) []string {
+	cWord := C.CString(word)
+	defer C.free(unsafe.Pointer(cWord))
+
+	var carray **C.char
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.handle == nil {
+		return nil
+	}
+
+	length := C.Hunspell_analyze(c.handle, &carray, cWord)
+
+	defer C.Hunspell_free_list(c.handle, &carray, length)
+
+	analysis := goStringSlice(carray, int(length))
+
+	return analysis
+}
+
+// Generate returns the forms of the word that match the morphology of the
+// example word, f.ex. "Johanssons" for "Johansson" with the example
+// "Anderssons".
+func (c *Checker) Generate(word string, example string) []string {
+	cWord := C.CString(word)
+	defer C.free(unsafe.Pointer(cWord))
+
+	cExample := C.CString(example)
+	defer C.free(unsafe.Pointer(cExample))
+
+	var carray **C.char
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.handle == nil {
+		return nil
+	}
+
+	length := C.Hunspell_generate(c.handle, &carray, cWord, cExample)
+
+	defer C.Hunspell_free_list(c.handle, &carray, length)
+
+	forms := goStringSlice(carray, int(length))
+
+	return forms
+}
+
 func (c *Checker) Spell(word string) bool {
 	cWord := C.CString(word)
 	defer C.free(unsafe.Pointer(cWord))
 
 	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.handle == nil {
+		return false
+	}
+
 	res := C.Hunspell_spell(c.handle, cWord)
-	c.m.Unlock()
 
 	return int(res) != 0
 }
 
+// SpellMany checks several words while only taking the lock once. The
+// results are returned in the same order as the words.
+func (c *Checker) SpellMany(words []string) []bool {
+	res := make([]bool, len(words))
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if c.handle == nil {
+		return res
+	}
+
+	for i, word := range words {
+		cWord := C.CString(word)
+
+		res[i] = int(C.Hunspell_spell(c.handle, cWord)) != 0
+
+		C.free(unsafe.Pointer(cWord))
+	}
+
+	return res
+}
+
 func goStringSlice(c **C.char, l int) []string {
 	s := make([]string, l)
 	cArray := unsafe.Slice(c, l)
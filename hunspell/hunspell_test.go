@@ -1,7 +1,11 @@
 package hunspell_test
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ttab/elephant-spell/hunspell"
 	"github.com/ttab/elephantine/test"
@@ -14,6 +18,10 @@ func TestChecker(t *testing.T) {
 	)
 	test.Must(t, err, "create spellchecker")
 
+	defer c.Close()
+
+	test.Equal(t, "UTF-8", c.Encoding(), "get the dictionary encoding")
+
 	suggestions := c.Suggest("paralell")
 	test.EqualDiff(t, []string{"parallell"}, suggestions,
 		"suggest the correct spelling of 'parallell'")
@@ -25,10 +33,23 @@ func TestChecker(t *testing.T) {
 		"höge",
 	}, suggestions2, "make suggestions for 'hööger'")
 
+	test.EqualDiff(t,
+		[]bool{true, false, true},
+		c.SpellMany([]string{"skolorna", "paralell", "höger"}),
+		"check several words at once")
+
 	stem := c.Stem("skolorna")
 	test.EqualDiff(t, []string{"skola"}, stem,
 		"stem 'skolor'")
 
+	analysis := c.Analyze("skolorna")
+	test.Equal(t, true, len(analysis) > 0, "analyse 'skolorna'")
+
+	for _, a := range analysis {
+		test.Equal(t, true, strings.Contains(a, "st:skola"),
+			"the analysis %q should have 'skola' as the stem", a)
+	}
+
 	const foreignWord = "al-Fatiha"
 
 	fOk := c.Spell(foreignWord)
@@ -40,3 +61,211 @@ func TestChecker(t *testing.T) {
 	fOk = c.Spell(foreignWord)
 	test.Equal(t, true, fOk, "%q should be accepted after add", foreignWord)
 }
+
+func TestCheckerClose(t *testing.T) {
+	c, err := hunspell.NewChecker(
+		"../dictionaries/sv_SE.aff",
+		"../dictionaries/sv_SE.dic",
+	)
+	test.Must(t, err, "create spellchecker")
+
+	test.Equal(t, true, c.Spell("skola"), "accept 'skola' before close")
+
+	test.Must(t, c.Close(), "close the checker")
+	test.Must(t, c.Close(), "close the checker a second time")
+
+	test.Equal(t, false, c.Spell("skola"), "reject words after close")
+	test.Equal(t, 0, len(c.Suggest("paralell")), "no suggestions after close")
+	test.Equal(t, 0, len(c.Stem("skolorna")), "no stems after close")
+	test.Equal(t, 0, len(c.Generate("skola", "bilarna")),
+		"no generated forms after close")
+	test.Equal(t, false, c.Add("al-Fatiha"), "fail to add words after close")
+}
+
+func TestPool(t *testing.T) {
+	p, err := hunspell.NewPool(
+		"../dictionaries/sv_SE.aff",
+		"../dictionaries/sv_SE.dic",
+		3,
+	)
+	test.Must(t, err, "create checker pool")
+
+	defer p.Close()
+
+	test.Equal(t, 3, p.Size(), "get the requested pool size")
+
+	const foreignWord = "al-Fatiha"
+
+	addOk := p.Add(foreignWord)
+	test.Equal(t, true, addOk, "add %q", foreignWord)
+
+	// Hold on to all checkers so that we can verify that the word was
+	// added to each of them.
+	for range p.Size() {
+		c, release := p.Acquire()
+
+		defer release()
+
+		test.Equal(t, true, c.Spell(foreignWord),
+			"%q should be accepted by all checkers", foreignWord)
+	}
+}
+
+func TestPoolGlossary(t *testing.T) {
+	glossaryPath := filepath.Join(t.TempDir(), "sv_SE.medicin.dic")
+
+	err := os.WriteFile(glossaryPath,
+		[]byte("2\nadalimumab\netanercept\n"), 0o600)
+	test.Must(t, err, "write glossary")
+
+	p, err := hunspell.NewPool(
+		"../dictionaries/sv_SE.aff",
+		"../dictionaries/sv_SE.dic",
+		1,
+	)
+	test.Must(t, err, "create checker pool")
+
+	defer p.Close()
+
+	g, err := hunspell.NewPool(
+		"../dictionaries/sv_SE.aff",
+		"../dictionaries/sv_SE.dic",
+		2,
+	)
+	test.Must(t, err, "create glossary pool")
+
+	err = g.AddDictionary(glossaryPath)
+	test.Must(t, err, "load the glossary")
+
+	p.AddGlossary("medicin", g)
+
+	test.EqualDiff(t, []string{"medicin"}, p.Glossaries(),
+		"list the glossaries")
+
+	got, ok := p.Glossary("medicin")
+	test.Equal(t, true, ok, "get the glossary")
+
+	test.Equal(t, false, p.Spell("adalimumab"),
+		"glossary words aren't known to the base dictionary")
+	test.Equal(t, true, got.Spell("adalimumab"),
+		"glossary words are known to the glossary")
+	test.Equal(t, true, got.Spell("skolorna"),
+		"the glossary is layered on top of the dictionary")
+
+	const foreignWord = "al-Fatiha"
+
+	p.Add(foreignWord)
+
+	test.Equal(t, true, got.Spell(foreignWord),
+		"words added to the pool are added to the glossary")
+
+	p.Remove(foreignWord)
+
+	test.Equal(t, false, got.Spell(foreignWord),
+		"words removed from the pool are removed from the glossary")
+
+	err = g.AddDictionary(filepath.Join(t.TempDir(), "missing.dic"))
+	test.Equal(t, true, err != nil, "fail on a missing glossary")
+}
+
+func TestPoolRetire(t *testing.T) {
+	p, err := hunspell.NewPool(
+		"../dictionaries/sv_SE.aff",
+		"../dictionaries/sv_SE.dic",
+		2,
+	)
+	test.Must(t, err, "create checker pool")
+
+	_, release := p.Acquire()
+
+	retired := make(chan error)
+
+	go func() {
+		retired <- p.Retire()
+	}()
+
+	select {
+	case <-retired:
+		t.Fatal("retire must wait for acquired checkers to be released")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+
+	test.Must(t, <-retired, "retire the pool")
+	test.Equal(t, false, p.Add("al-Fatiha"), "fail to add words after retire")
+}
+
+func TestPoolHold(t *testing.T) {
+	p, err := hunspell.NewPool(
+		"../dictionaries/sv_SE.aff",
+		"../dictionaries/sv_SE.dic",
+		1,
+	)
+	test.Must(t, err, "create checker pool")
+
+	release, ok := p.Hold()
+	test.Equal(t, true, ok, "hold the pool")
+
+	retired := make(chan error)
+
+	go func() {
+		retired <- p.Retire()
+	}()
+
+	select {
+	case <-retired:
+		t.Fatal("retire must wait for holds to be released")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, ok = p.Hold()
+	test.Equal(t, false, ok, "refuse new holds while retiring")
+
+	// Checkers can still be acquired by the holder, f.ex. for every
+	// chunk of a stream.
+	for range 3 {
+		test.Equal(t, true, p.Spell("skolorna"),
+			"use the pool while it's held")
+	}
+
+	release()
+	release()
+
+	test.Must(t, <-retired, "retire the pool")
+}
+
+func TestCheckerMissingDictionary(t *testing.T) {
+	_, err := hunspell.NewChecker(
+		"../dictionaries/sv_SE.aff",
+		"../dictionaries/xx_XX.dic",
+	)
+	test.MustNot(t, err, "create spellchecker with a missing dictionary")
+
+	_, err = hunspell.NewPool(
+		"../dictionaries/xx_XX.aff",
+		"../dictionaries/sv_SE.dic",
+		2,
+	)
+	test.MustNot(t, err, "create checker pool with a missing affix file")
+}
+
+func TestCheckerAddWithAffix(t *testing.T) {
+	c, err := hunspell.NewChecker(
+		"../dictionaries/sv_SE.aff",
+		"../dictionaries/sv_SE.dic",
+	)
+	test.Must(t, err, "create spellchecker")
+
+	defer c.Close()
+
+	test.Equal(t, false, c.Spell("Wetterbergs"),
+		"'Wetterbergs' should not be known from start")
+
+	addOk := c.AddWithAffix("Wetterberg", "Svensson")
+	test.Equal(t, true, addOk, "add 'Wetterberg' modelled on 'Svensson'")
+
+	test.Equal(t, true, c.Spell("Wetterberg"), "accept 'Wetterberg'")
+	test.Equal(t, true, c.Spell("Wetterbergs"),
+		"accept the genitive 'Wetterbergs'")
+}
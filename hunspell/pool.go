@@ -0,0 +1,274 @@
+package hunspell
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Pool is a set of checkers that have loaded the same dictionary. Checks can
+// run concurrently on the different checkers instead of queueing up behind a
+// single hunspell handle.
+type Pool struct {
+	all  []*Checker
+	free chan *Checker
+
+	// glossaries are pools that have loaded a glossary on top of the same
+	// dictionary, keyed by glossary name. Words that are added to or
+	// removed from the pool are also added to or removed from the
+	// glossaries.
+	glossaries map[string]*Pool
+
+	// holdM guards retired, so that no holds are added once the pool has
+	// started retiring.
+	holdM   sync.Mutex
+	retired bool
+	holds   sync.WaitGroup
+}
+
+// NewPool creates a pool of size checkers, a pool always has at least one
+// checker.
+func NewPool(affixPath string, dictPath string, size int) (*Pool, error) {
+	size = max(size, 1)
+
+	p := Pool{
+		free: make(chan *Checker, size),
+	}
+
+	for i := range size {
+		c, err := NewChecker(affixPath, dictPath)
+		if err != nil {
+			_ = p.Close()
+
+			return nil, fmt.Errorf("create checker %d: %w", i, err)
+		}
+
+		p.all = append(p.all, c)
+		p.free <- c
+	}
+
+	return &p, nil
+}
+
+// Acquire takes a checker from the pool, blocking until one is available. The
+// returned release function must be called to put the checker back in the
+// pool.
+func (p *Pool) Acquire() (*Checker, func()) {
+	c := <-p.free
+
+	return c, func() {
+		p.free <- c
+	}
+}
+
+// Hold registers a user of the pool, Retire won't close the checkers until
+// every hold has been released. The returned release function must be called
+// when the pool no longer is used. Returns false if the pool is being
+// retired.
+func (p *Pool) Hold() (func(), bool) {
+	p.holdM.Lock()
+	defer p.holdM.Unlock()
+
+	if p.retired {
+		return nil, false
+	}
+
+	p.holds.Add(1)
+
+	return sync.OnceFunc(p.holds.Done), true
+}
+
+// Size returns the number of checkers in the pool.
+func (p *Pool) Size() int {
+	return len(p.all)
+}
+
+// Encoding returns the character encoding of the loaded dictionary.
+func (p *Pool) Encoding() string {
+	return p.all[0].Encoding()
+}
+
+func (p *Pool) Spell(word string) bool {
+	c, release := p.Acquire()
+	defer release()
+
+	return c.Spell(word)
+}
+
+// SpellMany checks several words using a single checker.
+func (p *Pool) SpellMany(words []string) []bool {
+	c, release := p.Acquire()
+	defer release()
+
+	return c.SpellMany(words)
+}
+
+func (p *Pool) Suggest(word string) []string {
+	c, release := p.Acquire()
+	defer release()
+
+	return c.Suggest(word)
+}
+
+func (p *Pool) Stem(word string) []string {
+	c, release := p.Acquire()
+	defer release()
+
+	return c.Stem(word)
+}
+
+func (p *Pool) Analyze(word string) []string {
+	c, release := p.Acquire()
+	defer release()
+
+	return c.Analyze(word)
+}
+
+func (p *Pool) Generate(word string, example string) []string {
+	c, release := p.Acquire()
+	defer release()
+
+	return c.Generate(word, example)
+}
+
+// Add adds a word to all the checkers in the pool and its glossaries.
+func (p *Pool) Add(word string) bool {
+	ok := true
+
+	for _, c := range p.all {
+		ok = c.Add(word) && ok
+	}
+
+	for _, g := range p.glossaries {
+		ok = g.Add(word) && ok
+	}
+
+	return ok
+}
+
+// AddWithAffix adds a word with the same affix rules as the example word to
+// all the checkers in the pool and its glossaries.
+func (p *Pool) AddWithAffix(word string, example string) bool {
+	ok := true
+
+	for _, c := range p.all {
+		ok = c.AddWithAffix(word, example) && ok
+	}
+
+	for _, g := range p.glossaries {
+		ok = g.AddWithAffix(word, example) && ok
+	}
+
+	return ok
+}
+
+// Remove removes a word from all the checkers in the pool and its
+// glossaries.
+func (p *Pool) Remove(word string) bool {
+	ok := true
+
+	for _, c := range p.all {
+		ok = c.Remove(word) && ok
+	}
+
+	for _, g := range p.glossaries {
+		ok = g.Remove(word) && ok
+	}
+
+	return ok
+}
+
+// AddDictionary loads an additional dictionary in all the checkers in the
+// pool.
+func (p *Pool) AddDictionary(dictPath string) error {
+	for i, c := range p.all {
+		err := c.AddDictionary(dictPath)
+		if err != nil {
+			return fmt.Errorf("checker %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// AddGlossary registers a pool that has loaded a glossary on top of the same
+// dictionary as this pool. The glossary pool is closed together with this
+// pool.
+func (p *Pool) AddGlossary(name string, glossary *Pool) {
+	if p.glossaries == nil {
+		p.glossaries = make(map[string]*Pool)
+	}
+
+	p.glossaries[name] = glossary
+}
+
+// Glossary returns the pool for a glossary.
+func (p *Pool) Glossary(name string) (*Pool, bool) {
+	g, ok := p.glossaries[name]
+
+	return g, ok
+}
+
+// Glossaries returns the names of the glossaries in sorted order.
+func (p *Pool) Glossaries() []string {
+	return slices.Sorted(maps.Keys(p.glossaries))
+}
+
+// Retire waits for all holds to be released and all checkers to be returned
+// to the pool, and then closes them, together with its glossaries. No new
+// holds are accepted once Retire has been called.
+func (p *Pool) Retire() error {
+	p.holdM.Lock()
+	p.retired = true
+	p.holdM.Unlock()
+
+	p.holds.Wait()
+
+	var errs []error
+
+	for _, g := range p.glossaries {
+		err := g.Retire()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for range p.all {
+		<-p.free
+	}
+
+	errs = append(errs, p.closeCheckers())
+
+	return errors.Join(errs...)
+}
+
+// Close closes all the checkers in the pool and its glossaries.
+func (p *Pool) Close() error {
+	var errs []error
+
+	for _, g := range p.glossaries {
+		err := g.Close()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	errs = append(errs, p.closeCheckers())
+
+	return errors.Join(errs...)
+}
+
+func (p *Pool) closeCheckers() error {
+	var errs []error
+
+	for _, c := range p.all {
+		err := c.Close()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ttab/elephant-spell/hunspell"
+	"github.com/twitchtv/twirp"
+)
+
+// LanguageAuto can be used instead of a language code to have the language of
+// the text detected.
+const LanguageAuto = "auto"
+
+// detectionSampleSize is the maximum number of words that we check against
+// every dictionary when detecting the language of a text.
+const detectionSampleSize = 200
+
+// LanguageScore is the fraction of the words in a text that were recognised
+// by the dictionary for a language.
+type LanguageScore struct {
+	Language string  `json:"language"`
+	Score    float64 `json:"score"`
+}
+
+// detectLanguage scores the texts against all loaded dictionaries and returns
+// the scores, best match first.
+func (a *Application) detectLanguage(texts []string) ([]LanguageScore, error) {
+	a.m.RLock()
+
+	checkers := make(map[string]*hunspell.Pool, len(a.checkers))
+
+	for language, checker := range a.checkers {
+		release, ok := checker.Hold()
+		if !ok {
+			continue
+		}
+
+		defer release()
+
+		checkers[language] = checker
+	}
+
+	a.m.RUnlock()
+
+	languages := slices.Collect(maps.Keys(checkers))
+
+	if len(languages) == 0 {
+		return nil, twirp.Unavailable.Error("no dictionaries loaded")
+	}
+
+	// Sort the languages so that ties are resolved the same way every
+	// time.
+	slices.Sort(languages)
+
+	scores := make([]LanguageScore, 0, len(languages))
+
+	for _, language := range languages {
+		checker := checkers[language]
+		segmentation := a.segmentation(language)
+
+		var total, recognised int
+
+	texts:
+		for _, text := range texts {
+			for word := range segmentation.Words([]byte(text)) {
+				if total == detectionSampleSize {
+					break texts
+				}
+
+				total++
+
+				if checker.Spell(word) {
+					recognised++
+				}
+			}
+		}
+
+		if total == 0 {
+			return nil, twirp.InvalidArgumentError("text",
+				"no words to detect the language from")
+		}
+
+		scores = append(scores, LanguageScore{
+			Language: language,
+			Score:    float64(recognised) / float64(total),
+		})
+	}
+
+	slices.SortStableFunc(scores, func(a, b LanguageScore) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	return scores, nil
+}
+
+// isAutoLanguage returns true if the language should be detected.
+func isAutoLanguage(language string) bool {
+	return strings.EqualFold(language, LanguageAuto)
+}
@@ -0,0 +1,365 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dghubble/trie"
+	"github.com/ttab/elephant-spell/dictionaries"
+	"github.com/ttab/elephant-spell/hunspell"
+	"github.com/ttab/elephant-spell/postgres"
+	"github.com/ttab/elephantine"
+	"github.com/ttab/elephantine/pg"
+)
+
+// dictionarySources returns the filesystems to load dictionaries from. The
+// embedded dictionaries are always included, dictionaries in the configured
+// directory override the embedded ones for the same language.
+func dictionarySources(dir string) []fs.FS {
+	sources := []fs.FS{dictionaries.GetFS()}
+
+	if dir != "" {
+		sources = append(sources, os.DirFS(dir))
+	}
+
+	return sources
+}
+
+// loadCheckers creates a pool of hunspell checkers for every dictionary in
+// the sources, keyed by language code. A dictionary in a later source
+// replaces a dictionary for the same language in an earlier source.
+//
+// Glossaries are .dic files named after the dictionary and the glossary, f.ex.
+// "sv_SE.medicin.dic". They're loaded on top of the dictionary in separate
+// pools that are registered as glossaries of the dictionary pool.
+func loadCheckers(
+	logger *slog.Logger, sources []fs.FS, poolSize int,
+) (_ map[string]*hunspell.Pool, outErr error) {
+	// We need to set up a directory with our dictionaries so that hunspell
+	// can load them.
+	tmpDir, err := os.MkdirTemp("", "spell-dicts-*")
+	if err != nil {
+		return nil, fmt.Errorf("create dictionary directory: %w", err)
+	}
+
+	defer func() {
+		err := os.RemoveAll(tmpDir)
+		if err != nil {
+			outErr = errors.Join(outErr, fmt.Errorf(
+				"clean up temporary dictionary files: %w", err))
+		}
+	}()
+
+	// Dictionary names, f.ex. "sv_SE", mapped to the source to load them
+	// from.
+	dictSources := make(map[string]fs.FS)
+	// Glossary names, f.ex. "sv_SE.medicin", mapped to the source to load
+	// them from.
+	glossarySources := make(map[string]fs.FS)
+
+	for i, source := range sources {
+		dictFiles, err := fs.Glob(source, "*.dic")
+		if err != nil {
+			return nil, fmt.Errorf("list dictionaries in source %d: %w",
+				i, err)
+		}
+
+		for _, name := range dictFiles {
+			name = strings.TrimSuffix(name, ".dic")
+
+			if strings.Contains(name, ".") {
+				glossarySources[name] = source
+			} else {
+				dictSources[name] = source
+			}
+		}
+	}
+
+	// Copy the dictionaries and glossaries to the temp dir.
+	for lang, source := range dictSources {
+		for _, name := range []string{lang + ".aff", lang + ".dic"} {
+			err := copyDictionaryFile(source, tmpDir, name)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for glossary, source := range glossarySources {
+		err := copyDictionaryFile(source, tmpDir, glossary+".dic")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	checkers := make(map[string]*hunspell.Pool, len(dictSources))
+
+	defer func() {
+		if outErr == nil {
+			return
+		}
+
+		for _, checker := range checkers {
+			_ = checker.Close()
+		}
+	}()
+
+	// Instantiate a pool of hunspell checkers per language.
+	for lang := range dictSources {
+		checker, err := hunspell.NewPool(
+			filepath.Join(tmpDir, lang+".aff"),
+			filepath.Join(tmpDir, lang+".dic"),
+			poolSize,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("create hunspell checker for %q: %w",
+				lang, err)
+		}
+
+		// Convert from sv_SE to sv-se.
+		code := NormalizeLanguage(lang)
+
+		encoding := checker.Encoding()
+
+		if strings.EqualFold(encoding, "UTF-8") {
+			logger.Info("loaded dictionary",
+				"language", code,
+				"encoding", encoding)
+		} else {
+			logger.Warn("loaded dictionary that isn't UTF-8 encoded",
+				"language", code,
+				"encoding", encoding)
+		}
+
+		checkers[code] = checker
+
+		err = loadGlossaries(logger, checker, tmpDir, lang,
+			glossarySources, poolSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for glossary := range glossarySources {
+		lang, _, _ := strings.Cut(glossary, ".")
+
+		_, ok := dictSources[lang]
+		if !ok {
+			logger.Warn("ignoring glossary without a dictionary",
+				"glossary", glossary)
+		}
+	}
+
+	return checkers, nil
+}
+
+// loadGlossaries creates a pool for every glossary of a dictionary, with the
+// glossary loaded on top of the dictionary, and registers it with the
+// dictionary pool.
+func loadGlossaries(
+	logger *slog.Logger, checker *hunspell.Pool, dir string, lang string,
+	glossarySources map[string]fs.FS, poolSize int,
+) error {
+	for glossary := range glossarySources {
+		glossaryLang, name, _ := strings.Cut(glossary, ".")
+		if glossaryLang != lang {
+			continue
+		}
+
+		g, err := hunspell.NewPool(
+			filepath.Join(dir, lang+".aff"),
+			filepath.Join(dir, lang+".dic"),
+			poolSize,
+		)
+		if err != nil {
+			return fmt.Errorf("create hunspell checker for glossary %q: %w",
+				glossary, err)
+		}
+
+		// Registered first so that it's closed with the dictionary
+		// pool if the glossary can't be loaded.
+		checker.AddGlossary(strings.ToLower(name), g)
+
+		err = g.AddDictionary(filepath.Join(dir, glossary+".dic"))
+		if err != nil {
+			return fmt.Errorf("load glossary %q: %w", glossary, err)
+		}
+
+		logger.Info("loaded glossary",
+			"language", NormalizeLanguage(lang),
+			"glossary", strings.ToLower(name))
+	}
+
+	return nil
+}
+
+// copyDictionaryFile copies a dictionary file from the source to the
+// directory.
+func copyDictionaryFile(source fs.FS, dir string, name string) error {
+	data, err := fs.ReadFile(source, name)
+	if err != nil {
+		return fmt.Errorf("read dictionary %q: %w", name, err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, name), data, 0o600)
+	if err != nil {
+		return fmt.Errorf("copy dictionary %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// reloadDictionaries loads the base dictionaries again and swaps them in,
+// together with the custom entries, without disrupting ongoing checks.
+// Returns the languages that are supported after the reload.
+func (a *Application) reloadDictionaries(ctx context.Context) ([]string, error) {
+	a.reloadM.Lock()
+	defer a.reloadM.Unlock()
+
+	checkers, err := loadCheckers(a.logger,
+		dictionarySources(a.p.DictionaryDir), a.p.CheckerPoolSize)
+	if err != nil {
+		return nil, fmt.Errorf("load dictionaries: %w", err)
+	}
+
+	a.m.RLock()
+
+	var added []string
+
+	for language := range checkers {
+		_, ok := a.checkers[language]
+		if !ok {
+			added = append(added, language)
+		}
+	}
+
+	a.m.RUnlock()
+
+	// Read the custom entries of new languages before we take the write
+	// lock.
+	var addedRows []postgres.Entry
+
+	readStart := time.Now()
+
+	for _, language := range added {
+		rows, err := a.readLanguageEntries(ctx, language)
+		if err != nil {
+			for _, checker := range checkers {
+				_ = checker.Close()
+			}
+
+			return nil, err
+		}
+
+		addedRows = append(addedRows, rows...)
+	}
+
+	a.m.Lock()
+
+	for language, checker := range checkers {
+		phrases, ok := a.phrases[language]
+		if !ok {
+			phrases = trie.NewRuneTrie()
+			a.phrases[language] = phrases
+		}
+
+		for text := range a.loaded[language] {
+			p, ok := phrases.Get(text).(*phrase)
+			if !ok || p.Text != text {
+				checker.Add(text)
+
+				continue
+			}
+
+			addEntryWords(checker, p.Text, p.AffixModel, p.Forms)
+		}
+	}
+
+	for _, row := range addedRows {
+		a.loadEntry(checkers[row.Language], a.phrases[row.Language], row)
+	}
+
+	for _, language := range added {
+		a.syncedAt[language] = readStart
+	}
+
+	for language := range a.checkers {
+		_, ok := checkers[language]
+		if !ok {
+			delete(a.phrases, language)
+			delete(a.loaded, language)
+			delete(a.phraseLength, language)
+			delete(a.syncedAt, language)
+		}
+	}
+
+	old := a.checkers
+	a.checkers = checkers
+
+	a.m.Unlock()
+
+	a.suggestions.Purge()
+
+	// Retiring waits for the checks that hold the replaced checkers, f.ex.
+	// long batches and streams, to finish. Every language is retired on
+	// its own, so that a long stream doesn't keep the other languages
+	// around.
+	for language, checker := range old {
+		go func() {
+			err := checker.Retire()
+			if err != nil {
+				a.logger.Error("failed to close replaced checker",
+					elephantine.LogKeyError, err,
+					"language", language)
+			}
+		}()
+	}
+
+	languages := make([]string, 0, len(checkers))
+
+	for language := range checkers {
+		languages = append(languages, language)
+	}
+
+	slices.Sort(languages)
+
+	return languages, nil
+}
+
+// readLanguageEntries reads all custom entries for a language.
+func (a *Application) readLanguageEntries(
+	ctx context.Context, language string,
+) ([]postgres.Entry, error) {
+	params := postgres.IterateEntriesParams{
+		Language: pg.Text(language),
+		Limit:    200,
+	}
+
+	var entries []postgres.Entry
+
+	for {
+		rows, err := a.q.IterateEntries(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("read %s entries: %w", language, err)
+		}
+
+		entries = append(entries, rows...)
+
+		if int64(len(rows)) < params.Limit {
+			return entries, nil
+		}
+
+		last := rows[len(rows)-1]
+
+		params.AfterLanguage = last.Language
+		params.AfterEntry = last.Entry
+	}
+}
@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/ttab/elephant-spell/postgres"
+	"github.com/ttab/elephantine/pg"
+)
+
+// EntryAction is the kind of change that is recorded in the entry history.
+type EntryAction string
+
+const (
+	EntryActionSet     EntryAction = "set"
+	EntryActionDelete  EntryAction = "delete"
+	EntryActionRestore EntryAction = "restore"
+)
+
+// setEntryWithHistory writes an entry and records the change in the entry
+// history.
+func setEntryWithHistory(
+	ctx context.Context, q *postgres.Queries, params postgres.SetEntryParams,
+) error {
+	current, err := currentEntry(ctx, q, params.Language, params.Entry)
+	if err != nil {
+		return err
+	}
+
+	err = q.SetEntry(ctx, params)
+	if err != nil {
+		return fmt.Errorf("write entry: %w", err)
+	}
+
+	return addEntryHistory(ctx, q, postgres.AddEntryHistoryParams{
+		Language:  params.Language,
+		Entry:     params.Entry,
+		Action:    string(EntryActionSet),
+		OldStatus: entryStatus(current),
+		NewStatus: pg.Text(params.Status),
+		UpdatedBy: params.UpdatedBy,
+	})
+}
+
+// deleteEntryWithHistory marks an entry as deleted and records the change in
+// the entry history. Deleting an entry that doesn't exist is a no-op.
+func deleteEntryWithHistory(
+	ctx context.Context, q *postgres.Queries, params postgres.DeleteEntryParams,
+) error {
+	current, err := currentEntry(ctx, q, params.Language, params.Entry)
+	if err != nil {
+		return err
+	}
+
+	status := entryStatus(current)
+	if !status.Valid {
+		return nil
+	}
+
+	err = q.DeleteEntry(ctx, params)
+	if err != nil {
+		return fmt.Errorf("delete entry: %w", err)
+	}
+
+	return addEntryHistory(ctx, q, postgres.AddEntryHistoryParams{
+		Language:  params.Language,
+		Entry:     params.Entry,
+		Action:    string(EntryActionDelete),
+		OldStatus: status,
+		UpdatedBy: params.UpdatedBy,
+	})
+}
+
+// deleteEntriesWithHistory marks all entries that match a LIKE pattern as
+// deleted and records the changes in the entry history. Returns the texts of
+// the deleted entries.
+func deleteEntriesWithHistory(
+	ctx context.Context, q *postgres.Queries,
+	params postgres.DeleteEntriesByPrefixParams,
+) ([]string, error) {
+	rows, err := q.DeleteEntriesByPrefix(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("delete entries: %w", err)
+	}
+
+	texts := make([]string, len(rows))
+
+	for i, row := range rows {
+		err := addEntryHistory(ctx, q, postgres.AddEntryHistoryParams{
+			Language:  params.Language,
+			Entry:     row.Entry,
+			Action:    string(EntryActionDelete),
+			OldStatus: pg.Text(row.Status),
+			UpdatedBy: params.UpdatedBy,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		texts[i] = row.Entry
+	}
+
+	return texts, nil
+}
+
+// restoreEntryWithHistory restores a deleted entry and records the change in
+// the entry history. Returns false if there was no deleted entry to restore.
+func restoreEntryWithHistory(
+	ctx context.Context, q *postgres.Queries, params postgres.RestoreEntryParams,
+) (bool, error) {
+	current, err := currentEntry(ctx, q, params.Language, params.Entry)
+	if err != nil {
+		return false, err
+	}
+
+	if current == nil || !current.DeletedAt.Valid {
+		return false, nil
+	}
+
+	restored, err := q.RestoreEntry(ctx, params)
+	if err != nil {
+		return false, fmt.Errorf("restore entry: %w", err)
+	}
+
+	if restored == 0 {
+		return false, nil
+	}
+
+	err = addEntryHistory(ctx, q, postgres.AddEntryHistoryParams{
+		Language:  params.Language,
+		Entry:     params.Entry,
+		Action:    string(EntryActionRestore),
+		NewStatus: pg.Text(current.Status),
+		UpdatedBy: params.UpdatedBy,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// currentEntry reads an entry and locks it for the rest of the transaction,
+// so that the history is recorded in the same order as the changes. Returns
+// nil if the entry doesn't exist.
+func currentEntry(
+	ctx context.Context, q *postgres.Queries, language string, text string,
+) (*postgres.Entry, error) {
+	row, err := q.GetEntryForUpdate(ctx, postgres.GetEntryForUpdateParams{
+		Language: language,
+		Entry:    text,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read current entry: %w", err)
+	}
+
+	return &row, nil
+}
+
+// entryStatus returns the status of the entry, or NULL if the entry doesn't
+// exist or has been deleted.
+func entryStatus(e *postgres.Entry) pgtype.Text {
+	if e == nil || e.DeletedAt.Valid {
+		return pgtype.Text{}
+	}
+
+	return pg.Text(e.Status)
+}
+
+func addEntryHistory(
+	ctx context.Context, q *postgres.Queries,
+	params postgres.AddEntryHistoryParams,
+) error {
+	err := q.AddEntryHistory(ctx, params)
+	if err != nil {
+		return fmt.Errorf("write entry history: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"github.com/dghubble/trie"
+	"github.com/ttab/elephant-spell/hunspell"
+	"github.com/ttab/elephant-spell/postgres"
+)
+
+// AddEntryWords adds an entry to the checkers like the entry loading does.
+func AddEntryWords(checker *hunspell.Pool, row postgres.Entry) {
+	addEntryWords(checker, row.Entry, row.AffixModel, row.Forms)
+}
+
+// PhraseTrie exposes the phrase trie handling of the entry updater to the
+// tests.
+type PhraseTrie struct {
+	trie *trie.RuneTrie
+}
+
+func NewPhraseTrie(rows ...postgres.Entry) *PhraseTrie {
+	t := PhraseTrie{trie: trie.NewRuneTrie()}
+
+	for _, row := range rows {
+		putEntry(t.trie, row)
+	}
+
+	return &t
+}
+
+// Replace replaces the updated entries with the rows.
+func (t *PhraseTrie) Replace(updates []string, rows ...postgres.Entry) {
+	u := make(map[string]bool, len(updates))
+
+	for _, text := range updates {
+		u[text] = true
+	}
+
+	replaceEntries(t.trie, u, rows)
+}
+
+// Lookup returns the entry that a phrase matches, or an empty string.
+func (t *PhraseTrie) Lookup(text string) string {
+	p, _ := lookupPhrase(t.trie, text)
+	if p == nil {
+		return ""
+	}
+
+	return p.Text
+}
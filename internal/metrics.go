@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	entryDrift        *prometheus.GaugeVec
+	textRequests      *prometheus.CounterVec
+	wordsChecked      *prometheus.CounterVec
+	wordsFlagged      *prometheus.CounterVec
+	suggestDuration   *prometheus.HistogramVec
+	suggestionCache   *prometheus.CounterVec
+	queryDuration     *prometheus.HistogramVec
+	listenerErrors    prometheus.Counter
+	entriesReconciled *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) (*metrics, error) {
+	m := metrics{
+		entryDrift: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "elephant_spell_entry_drift",
+				Help: "The number of entries in the database minus the number of entries loaded in memory.",
+			},
+			[]string{"language"},
+		),
+		textRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "elephant_spell_text_requests_total",
+				Help: "The number of text spellcheck requests.",
+			},
+			[]string{"language"},
+		),
+		wordsChecked: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "elephant_spell_words_checked_total",
+				Help: "The number of words that were checked against the dictionary.",
+			},
+			[]string{"language"},
+		),
+		wordsFlagged: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "elephant_spell_words_flagged_total",
+				Help: "The number of misspelled words and common mistakes that were found.",
+			},
+			[]string{"language"},
+		),
+		suggestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "elephant_spell_suggest_duration_seconds",
+				Help:    "How long it took to get suggestions for a misspelled word.",
+				Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+			},
+			[]string{"language"},
+		),
+		suggestionCache: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "elephant_spell_suggestion_cache_lookups_total",
+				Help: "The number of suggestion cache lookups, by result \"hit\" or \"miss\".",
+			},
+			[]string{"language", "result"},
+		),
+		queryDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "elephant_spell_query_duration_seconds",
+				Help:    "How long database queries took, including reading the results.",
+				Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+			},
+			[]string{"query"},
+		),
+		listenerErrors: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "elephant_spell_listener_errors_total",
+				Help: "The number of times the entry update listener failed and had to reconnect.",
+			},
+		),
+		entriesReconciled: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "elephant_spell_entries_reconciled_total",
+				Help: "The number of entries that were out of sync with the database and had to be reloaded.",
+			},
+			[]string{"language"},
+		),
+	}
+
+	err := reg.Register(m.entryDrift)
+	if err != nil {
+		return nil, fmt.Errorf("register entry drift metric: %w", err)
+	}
+
+	err = reg.Register(m.textRequests)
+	if err != nil {
+		return nil, fmt.Errorf("register text requests metric: %w", err)
+	}
+
+	err = reg.Register(m.wordsChecked)
+	if err != nil {
+		return nil, fmt.Errorf("register words checked metric: %w", err)
+	}
+
+	err = reg.Register(m.wordsFlagged)
+	if err != nil {
+		return nil, fmt.Errorf("register words flagged metric: %w", err)
+	}
+
+	err = reg.Register(m.suggestDuration)
+	if err != nil {
+		return nil, fmt.Errorf("register suggest duration metric: %w", err)
+	}
+
+	err = reg.Register(m.suggestionCache)
+	if err != nil {
+		return nil, fmt.Errorf("register suggestion cache metric: %w", err)
+	}
+
+	err = reg.Register(m.queryDuration)
+	if err != nil {
+		return nil, fmt.Errorf("register query duration metric: %w", err)
+	}
+
+	err = reg.Register(m.listenerErrors)
+	if err != nil {
+		return nil, fmt.Errorf("register listener error metric: %w", err)
+	}
+
+	err = reg.Register(m.entriesReconciled)
+	if err != nil {
+		return nil, fmt.Errorf("register entries reconciled metric: %w", err)
+	}
+
+	return &m, nil
+}
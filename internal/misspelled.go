@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ttab/elephant-api/spell"
+)
+
+// MergeDuplicateEntries collapses misspelled entries with the same text into
+// a single entry, merging their suggestions. Suggestions with the same text
+// are only kept once, with the description of the suggestion that has one.
+// The order of the first occurrence of each entry and suggestion is
+// preserved.
+func MergeDuplicateEntries(
+	entries []*spell.MisspelledEntry,
+) []*spell.MisspelledEntry {
+	if len(entries) == 0 {
+		return entries
+	}
+
+	merged := make([]*spell.MisspelledEntry, 0, len(entries))
+	index := make(map[string]*spell.MisspelledEntry, len(entries))
+
+	for _, e := range entries {
+		suggestions := e.Suggestions
+
+		first, ok := index[e.Text]
+		if !ok {
+			index[e.Text] = e
+			merged = append(merged, e)
+
+			first = e
+			first.Suggestions = nil
+		}
+
+		for _, s := range suggestions {
+			first.Suggestions = addSuggestion(first.Suggestions, s)
+		}
+	}
+
+	return merged
+}
+
+// LimitSuggestions truncates the suggestions of every entry to at most limit
+// suggestions, a limit of zero or less is unlimited. Suggestions from custom
+// entries are added before the hunspell suggestions, so they're the ones that
+// are kept.
+func LimitSuggestions(entries []*spell.MisspelledEntry, limit int) {
+	if limit <= 0 {
+		return
+	}
+
+	for _, e := range entries {
+		if len(e.Suggestions) > limit {
+			e.Suggestions = e.Suggestions[:limit]
+		}
+	}
+}
+
+// CustomSuggestionScore is the score of a correction from a custom entry,
+// which is always ranked above the hunspell suggestions.
+const CustomSuggestionScore = 1.0
+
+// HunspellSuggestionScore returns a score for a hunspell suggestion based on
+// its rank in the hunspell suggestions, starting at 0. Hunspell returns its
+// best guesses first, so the score halves from the first to the third
+// suggestion and keeps falling after that. Scores are always below
+// CustomSuggestionScore.
+func HunspellSuggestionScore(rank int) float64 {
+	return 1 / float64(rank+2)
+}
+
+// Suggester finds suggestions for misspelled words, it's implemented by the
+// hunspell checkers.
+type Suggester interface {
+	Suggest(word string) []string
+	Stem(word string) []string
+}
+
+// SuggestWithStemFallback returns the suggestions for a misspelled word. If
+// there are no suggestions the stems of the word are suggested instead, as
+// an inflection that hunspell can't correct often has a recognisable stem.
+func SuggestWithStemFallback(s Suggester, word string) []string {
+	suggestions := s.Suggest(word)
+	if len(suggestions) > 0 {
+		return suggestions
+	}
+
+	var stems []string
+
+	for _, stem := range s.Stem(word) {
+		if stem == word || slices.Contains(stems, stem) {
+			continue
+		}
+
+		stems = append(stems, stem)
+	}
+
+	return stems
+}
+
+// MatchCapitalization changes the case of the replacement to match the
+// text. If the text is all upper case the replacement is upper cased, and if
+// it starts with an upper case letter, f.ex. at the start of a sentence, the
+// first letter of the replacement is capitalised.
+func MatchCapitalization(text string, replacement string) string {
+	first, _ := utf8.DecodeRuneInString(text)
+	if !unicode.IsUpper(first) {
+		return replacement
+	}
+
+	if isAllCaps(text) {
+		return strings.ToUpper(replacement)
+	}
+
+	r, size := utf8.DecodeRuneInString(replacement)
+	if r == utf8.RuneError || unicode.IsUpper(r) {
+		return replacement
+	}
+
+	return string(unicode.ToUpper(r)) + replacement[size:]
+}
+
+// isAllCaps returns true if the text has more than one letter and all of
+// them are upper case.
+func isAllCaps(text string) bool {
+	var letters int
+
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		if !unicode.IsUpper(r) {
+			return false
+		}
+
+		letters++
+	}
+
+	return letters > 1
+}
+
+// addSuggestion adds a suggestion to the list unless there already is one
+// with the same text. A description is copied to the existing suggestion if
+// it doesn't have one.
+func addSuggestion(
+	list []*spell.Suggestion, s *spell.Suggestion,
+) []*spell.Suggestion {
+	for _, existing := range list {
+		if existing.Text != s.Text {
+			continue
+		}
+
+		if existing.Description == "" {
+			existing.Description = s.Description
+		}
+
+		return list
+	}
+
+	return append(list, s)
+}
+
+func hasSuggestion(list []*spell.Suggestion, text string) bool {
+	for _, s := range list {
+		if s.Text == text {
+			return true
+		}
+	}
+
+	return false
+}
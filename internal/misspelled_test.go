@@ -0,0 +1,214 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/ttab/elephant-api/spell"
+	"github.com/ttab/elephant-spell/internal"
+	"github.com/ttab/elephantine/test"
+)
+
+func TestMergeDuplicateEntries(t *testing.T) {
+	// "Vitryssland" is reported both as a common mistake for "Belarus" and
+	// by hunspell, as would happen if the phrase replacement didn't remove
+	// every occurrence.
+	entries := []*spell.MisspelledEntry{
+		{
+			Text: "Vitryssland",
+			Suggestions: []*spell.Suggestion{
+				{Text: "Belarus", Description: "Det nya namnet"},
+			},
+		},
+		{
+			Text: "rätstavad",
+			Suggestions: []*spell.Suggestion{
+				{Text: "rättstavad"},
+			},
+		},
+		{
+			Text: "Vitryssland",
+			Suggestions: []*spell.Suggestion{
+				{Text: "Belarus"},
+				{Text: "Vitrysslands"},
+			},
+		},
+	}
+
+	got := spell.Misspelled{
+		Entries: internal.MergeDuplicateEntries(entries),
+	}
+
+	want := spell.Misspelled{Entries: []*spell.MisspelledEntry{
+		{
+			Text: "Vitryssland",
+			Suggestions: []*spell.Suggestion{
+				{Text: "Belarus", Description: "Det nya namnet"},
+				{Text: "Vitrysslands"},
+			},
+		},
+		{
+			Text: "rätstavad",
+			Suggestions: []*spell.Suggestion{
+				{Text: "rättstavad"},
+			},
+		},
+	}}
+
+	test.EqualMessage(t, &want, &got, "merge the duplicate entries")
+}
+
+func TestMergeDuplicateSuggestions(t *testing.T) {
+	// The correction of the common mistake "Ålänningen" is also suggested
+	// by hunspell, once without and once with the description.
+	entries := []*spell.MisspelledEntry{
+		{
+			Text: "Ålänningen",
+			Suggestions: []*spell.Suggestion{
+				{Text: "Åländaren"},
+				{Text: "Åländaren"},
+				{Text: "Ålänning"},
+			},
+		},
+		{
+			Text: "Ålänningen",
+			Suggestions: []*spell.Suggestion{
+				{Text: "Åländaren", Description: "Rekommenderad form"},
+			},
+		},
+	}
+
+	got := spell.Misspelled{
+		Entries: internal.MergeDuplicateEntries(entries),
+	}
+
+	want := spell.Misspelled{Entries: []*spell.MisspelledEntry{
+		{
+			Text: "Ålänningen",
+			Suggestions: []*spell.Suggestion{
+				{Text: "Åländaren", Description: "Rekommenderad form"},
+				{Text: "Ålänning"},
+			},
+		},
+	}}
+
+	test.EqualMessage(t, &want, &got,
+		"keep one suggestion with the description")
+}
+
+func TestLimitSuggestions(t *testing.T) {
+	entries := []*spell.MisspelledEntry{
+		{
+			Text: "Vitryssland",
+			Suggestions: []*spell.Suggestion{
+				{Text: "Belarus", Description: "Det nya namnet"},
+				{Text: "Vitrysslands"},
+				{Text: "Vitrysslandet"},
+			},
+		},
+		{
+			Text: "rätstavad",
+			Suggestions: []*spell.Suggestion{
+				{Text: "rättstavad"},
+			},
+		},
+	}
+
+	internal.LimitSuggestions(entries, 0)
+
+	test.Equal(t, 3, len(entries[0].Suggestions),
+		"keep all suggestions without a limit")
+
+	internal.LimitSuggestions(entries, 2)
+
+	got := spell.Misspelled{Entries: entries}
+
+	want := spell.Misspelled{Entries: []*spell.MisspelledEntry{
+		{
+			Text: "Vitryssland",
+			Suggestions: []*spell.Suggestion{
+				{Text: "Belarus", Description: "Det nya namnet"},
+				{Text: "Vitrysslands"},
+			},
+		},
+		{
+			Text: "rätstavad",
+			Suggestions: []*spell.Suggestion{
+				{Text: "rättstavad"},
+			},
+		},
+	}}
+
+	test.EqualMessage(t, &want, &got, "limit the suggestions")
+}
+
+func TestMatchCapitalization(t *testing.T) {
+	cases := map[[2]string]string{
+		{"Rdaktionen", "redaktionen"}:    "Redaktionen",
+		{"rdaktionen", "redaktionen"}:    "redaktionen",
+		{"VITRYSSLAND", "Belarus"}:       "BELARUS",
+		{"EMMELLAN", "emellan"}:          "EMELLAN",
+		{"I", "i"}:                       "I",
+		{"Ålänningen", "åländaren"}:      "Åländaren",
+		{"Förhandlingar", "USA-besöket"}: "USA-besöket",
+	}
+
+	for in, want := range cases {
+		got := internal.MatchCapitalization(in[0], in[1])
+
+		test.Equal(t, want, got, "capitalisation of %q for %q", in[1], in[0])
+	}
+}
+
+type fakeSuggester struct {
+	suggestions map[string][]string
+	stems       map[string][]string
+}
+
+func (f fakeSuggester) Suggest(word string) []string {
+	return f.suggestions[word]
+}
+
+func (f fakeSuggester) Stem(word string) []string {
+	return f.stems[word]
+}
+
+func TestSuggestWithStemFallback(t *testing.T) {
+	s := fakeSuggester{
+		suggestions: map[string][]string{
+			"rätstavad": {"rättstavad"},
+		},
+		stems: map[string][]string{
+			"rätstavad":      {"rätstava"},
+			"skolornasarnas": {"skola", "skola"},
+		},
+	}
+
+	test.EqualDiff(t,
+		[]string{"rättstavad"},
+		internal.SuggestWithStemFallback(s, "rätstavad"),
+		"prefer the hunspell suggestions")
+
+	test.EqualDiff(t,
+		[]string{"skola"},
+		internal.SuggestWithStemFallback(s, "skolornasarnas"),
+		"fall back to the stem when there are no suggestions")
+
+	test.EqualDiff(t,
+		[]string(nil),
+		internal.SuggestWithStemFallback(s, "xyzzy"),
+		"no suggestions or stems")
+}
+
+func TestHunspellSuggestionScore(t *testing.T) {
+	test.Equal(t, 0.5, internal.HunspellSuggestionScore(0),
+		"score of the first suggestion")
+
+	for rank := range 10 {
+		score := internal.HunspellSuggestionScore(rank)
+
+		test.Equal(t, true, score < internal.CustomSuggestionScore,
+			"rank %d scores below custom entry corrections", rank)
+		test.Equal(t, true, score > internal.HunspellSuggestionScore(rank+1),
+			"rank %d scores above the next rank", rank)
+	}
+}
@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeText converts the text to Unicode normalization form C, so that
+// f.ex. an "å" written as "a" followed by a combining ring matches the
+// precomposed "å" used by the dictionaries and custom entries.
+func NormalizeText(text string) string {
+	return norm.NFC.String(text)
+}
+
+// NormalizedText is a text in Unicode normalization form C that can map
+// offsets back to the text that it was normalized from.
+type NormalizedText struct {
+	Text string
+
+	// changes are the segments of the text that were changed by the
+	// normalization, in order. Offsets between them differ from the
+	// original by the same amount as the end of the preceding change.
+	changes []normalizedSegment
+}
+
+type normalizedSegment struct {
+	Normalized TextRange
+	Original   TextRange
+}
+
+// NormalizeWithOffsets normalizes the text like NormalizeText, and keeps track
+// of the segments that were changed so that ranges in the normalized text can
+// be mapped back to the original text.
+func NormalizeWithOffsets(text string) NormalizedText {
+	if norm.NFC.IsNormalString(text) {
+		return NormalizedText{Text: text}
+	}
+
+	var (
+		n         NormalizedText
+		it        norm.Iter
+		buf       strings.Builder
+		normRunes int
+		origRunes int
+	)
+
+	it.InitString(norm.NFC, text)
+
+	for !it.Done() {
+		start := it.Pos()
+		normStart := buf.Len()
+
+		segment := it.Next()
+
+		buf.Write(segment)
+
+		original := text[start:it.Pos()]
+		segmentRunes := utf8.RuneCount(segment)
+		originalRunes := utf8.RuneCountInString(original)
+
+		if string(segment) != original {
+			n.changes = append(n.changes, normalizedSegment{
+				Normalized: TextRange{
+					Start:     normRunes,
+					End:       normRunes + segmentRunes,
+					StartByte: normStart,
+					EndByte:   buf.Len(),
+				},
+				Original: TextRange{
+					Start:     origRunes,
+					End:       origRunes + originalRunes,
+					StartByte: start,
+					EndByte:   it.Pos(),
+				},
+			})
+		}
+
+		normRunes += segmentRunes
+		origRunes += originalRunes
+	}
+
+	n.Text = buf.String()
+
+	return n
+}
+
+// Original maps a range in the normalized text to the original text. A range
+// that starts or ends inside a changed segment is widened to cover the whole
+// segment.
+func (n NormalizedText) Original(r TextRange) TextRange {
+	var o TextRange
+
+	o.Start, o.StartByte = n.originalOffset(r.Start, r.StartByte, false)
+	o.End, o.EndByte = n.originalOffset(r.End, r.EndByte, true)
+
+	return o
+}
+
+// originalOffset maps an offset in code points and bytes in the normalized
+// text to the original text. Offsets inside a changed segment are moved to
+// the end of the segment if end is true, and to the start otherwise.
+func (n NormalizedText) originalOffset(
+	runes int, bytes int, end bool,
+) (int, int) {
+	// The last change that starts at or before the offset.
+	i := sort.Search(len(n.changes), func(i int) bool {
+		return n.changes[i].Normalized.StartByte > bytes
+	}) - 1
+	if i < 0 {
+		return runes, bytes
+	}
+
+	c := n.changes[i]
+
+	switch {
+	case bytes == c.Normalized.StartByte:
+		return c.Original.Start, c.Original.StartByte
+	case bytes < c.Normalized.EndByte && end:
+		return c.Original.End, c.Original.EndByte
+	case bytes < c.Normalized.EndByte:
+		return c.Original.Start, c.Original.StartByte
+	}
+
+	return c.Original.End + runes - c.Normalized.End,
+		c.Original.EndByte + bytes - c.Normalized.EndByte
+}
+
+// NormalizeEntryText normalizes the text of an entry, a common mistake, or a
+// phrase that is matched against them. On top of NormalizeText leading and
+// trailing whitespace is removed and runs of internal whitespace are
+// collapsed into a single space, so that "Muammar  Gaddafi" is stored and
+// matched as "Muammar Gaddafi".
+//
+// Must not be used on texts that are checked as a whole, as the positions
+// of the words would change.
+func NormalizeEntryText(text string) string {
+	return strings.Join(strings.Fields(NormalizeText(text)), " ")
+}
+
+// NormalizeLanguage converts a language code to the lower case and hyphen
+// separated form that the dictionaries are keyed by, f.ex. "sv_SE" and
+// "SV-SE" to "sv-se".
+func NormalizeLanguage(code string) string {
+	return strings.ToLower(strings.ReplaceAll(
+		strings.TrimSpace(code), "_", "-"))
+}
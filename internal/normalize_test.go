@@ -0,0 +1,99 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/dghubble/trie"
+	"github.com/ttab/elephant-spell/internal"
+	"github.com/ttab/elephantine/test"
+)
+
+func TestNormalizeText(t *testing.T) {
+	// "Åländaren" with the "Å" and "ä" decomposed into a base letter and
+	// a combining mark, as pasted from f.ex. macOS.
+	const (
+		decomposed = "A\u030ala\u0308ndaren"
+		composed   = "Åländaren"
+	)
+
+	test.Equal(t, composed, internal.NormalizeText(decomposed),
+		"compose the decomposed text")
+	test.Equal(t, composed, internal.NormalizeText(composed),
+		"leave composed text as is")
+
+	entries := trie.NewRuneTrie()
+	entries.Put(composed, true)
+
+	test.Equal(t, false, entries.Get(decomposed) != nil,
+		"the decomposed text doesn't match the stored entry")
+	test.Equal(t, true, entries.Get(internal.NormalizeText(decomposed)) != nil,
+		"the normalized text matches the stored entry")
+}
+
+func TestNormalizeEntryText(t *testing.T) {
+	test.Equal(t, "Muammar Gaddafi",
+		internal.NormalizeEntryText("Muammar  Gaddafi"),
+		"collapse a double space")
+	test.Equal(t, "Muammar Gaddafi",
+		internal.NormalizeEntryText(" Muammar\t\nGaddafi "),
+		"collapse and trim other whitespace")
+	test.Equal(t, "Åländaren i Mariehamn",
+		internal.NormalizeEntryText("A\u030ala\u0308ndaren  i Mariehamn"),
+		"compose the text as well")
+	test.Equal(t, "", internal.NormalizeEntryText("  "),
+		"reduce whitespace only to an empty text")
+}
+
+func TestNormalizeWithOffsets(t *testing.T) {
+	// "Åländaren" with the "Å" and "ä" decomposed, as pasted from f.ex.
+	// macOS.
+	const text = "Om A\u030ala\u0308ndaren Khadaffi"
+
+	normalized := internal.NormalizeWithOffsets(text)
+
+	test.Equal(t, "Om Åländaren Khadaffi", normalized.Text,
+		"compose the text")
+
+	var seg internal.Segmentation
+
+	ranges := seg.Locate([]byte(normalized.Text), []string{
+		"Åländaren", "Khadaffi",
+	})
+
+	test.EqualDiff(t, internal.TextRange{
+		Start: 3, End: 14, StartByte: 3, EndByte: 16,
+	}, normalized.Original(ranges["Åländaren"][0]),
+		"map a range over the decomposed letters")
+
+	test.EqualDiff(t, internal.TextRange{
+		Start: 15, End: 23, StartByte: 17, EndByte: 25,
+	}, normalized.Original(ranges["Khadaffi"][0]),
+		"map a range after the decomposed letters")
+
+	// "lä" in "Åländaren".
+	test.EqualDiff(t, internal.TextRange{
+		Start: 5, End: 8, StartByte: 6, EndByte: 10,
+	}, normalized.Original(internal.TextRange{
+		Start: 4, End: 6, StartByte: 5, EndByte: 8,
+	}), "map a range between the decomposed letters")
+
+	plain := internal.NormalizeWithOffsets("Khadaffi")
+
+	test.EqualDiff(t, internal.TextRange{
+		Start: 1, End: 3, StartByte: 1, EndByte: 3,
+	}, plain.Original(internal.TextRange{
+		Start: 1, End: 3, StartByte: 1, EndByte: 3,
+	}), "keep the offsets of a normalized text")
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	for _, code := range []string{
+		"sv-se", "SV-SE", "sv_SE", "sv_se", "Sv-Se", " sv-se ",
+	} {
+		test.Equal(t, "sv-se", internal.NormalizeLanguage(code),
+			"normalize %q", code)
+	}
+
+	test.Equal(t, "", internal.NormalizeLanguage(""),
+		"leave an empty code empty")
+}
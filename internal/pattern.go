@@ -0,0 +1,38 @@
+package internal
+
+import "strings"
+
+// likeEscaper escapes the characters that have a special meaning in a LIKE
+// pattern, using the default escape character.
+var likeEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`%`, `\%`,
+	`_`, `\_`,
+)
+
+// EscapeLike escapes a text so that it only matches itself when used in a
+// LIKE pattern.
+func EscapeLike(text string) string {
+	return likeEscaper.Replace(text)
+}
+
+// prefixPattern creates a LIKE pattern that matches entries starting with the
+// given prefix. Prefix patterns can use the text_pattern_ops index on the
+// entries.
+func prefixPattern(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+
+	return EscapeLike(prefix) + "%"
+}
+
+// containsPattern creates a LIKE pattern that matches values containing the
+// given text.
+func containsPattern(text string) string {
+	if text == "" {
+		return ""
+	}
+
+	return "%" + EscapeLike(text) + "%"
+}
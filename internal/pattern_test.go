@@ -0,0 +1,23 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/ttab/elephant-spell/internal"
+	"github.com/ttab/elephantine/test"
+)
+
+func TestEscapeLike(t *testing.T) {
+	cases := map[string]string{
+		"Belarus":     "Belarus",
+		"snake_case":  `snake\_case`,
+		"100%":        `100\%`,
+		`C:\dokument`: `C:\\dokument`,
+		`_\%`:         `\_\\\%`,
+		"":            "",
+	}
+
+	for in, want := range cases {
+		test.Equal(t, want, internal.EscapeLike(in), "escape %q", in)
+	}
+}
@@ -1,15 +1,20 @@
 package internal
 
 import (
-	"bytes"
 	"strings"
 
 	"github.com/blevesearch/segment"
 )
 
 // PhraseIterator runs a sliding window over a text and yeilds all the word
-// sequence combinations
+// sequence combinations, using the default segmentation.
 func PhraseIterator(text []byte, phraseLength int) func(yield func(v string) bool) {
+	return Segmentation{}.Phrases(text, phraseLength)
+}
+
+// Phrases runs a sliding window over a text and yeilds all the word sequence
+// combinations
+func (s Segmentation) Phrases(text []byte, phraseLength int) func(yield func(v string) bool) {
 	// Circular buffer for the last N tokens.
 	window := make([]token, 0, phraseLength*4)
 	// The start of the circular buffer
@@ -19,13 +24,15 @@ func PhraseIterator(text []byte, phraseLength int) func(yield func(v string) boo
 
 	var buf strings.Builder
 
-	segmenter := segment.NewWordSegmenter(bytes.NewReader(text))
-
 	return func(yield func(v string) bool) {
-		for segmenter.Segment() {
-			t := token{
-				Text: segmenter.Text(),
-				Type: segmenter.Type(),
+		for t := range s.Tokens(text) {
+			// Start over after a sentence boundary so that no
+			// phrase spans it.
+			if s.isBoundary(t) {
+				window = window[0:0]
+				start = 0
+
+				continue
 			}
 
 			// Add the token to the circular buffer
@@ -82,6 +89,68 @@ func PhraseIterator(text []byte, phraseLength int) func(yield func(v string) boo
 	}
 }
 
+// NGramIterator yields all contiguous word sequences of one to n words in
+// document order, using the default segmentation.
+func NGramIterator(text []byte, n int) func(yield func(v string) bool) {
+	return Segmentation{}.NGrams(text, n)
+}
+
+// NGrams yields all contiguous word sequences of one to n words in document
+// order. All sequences starting at a word are yielded, shortest first, before
+// moving on to the next word. The separators between the words are kept as
+// they are in the text.
+func (s Segmentation) NGrams(text []byte, n int) func(yield func(v string) bool) {
+	return func(yield func(v string) bool) {
+		var (
+			tokens []token
+			// Indexes of the word tokens.
+			words []int
+			// The sentence that each word belongs to.
+			sentences []int
+			sentence  int
+		)
+
+		for t := range s.Tokens(text) {
+			if s.isBoundary(t) {
+				sentence++
+			}
+
+			if t.Type == segment.Letter {
+				words = append(words, len(tokens))
+				sentences = append(sentences, sentence)
+			}
+
+			tokens = append(tokens, t)
+		}
+
+		var buf strings.Builder
+
+		for i, start := range words {
+			buf.Reset()
+
+			end := start
+
+			for j, wordEnd := range words[i:min(i+n, len(words))] {
+				if sentences[i+j] != sentences[i] {
+					break
+				}
+
+				for _, t := range tokens[end:wordEnd] {
+					buf.WriteString(t.Text)
+				}
+
+				buf.WriteString(tokens[wordEnd].Text)
+
+				end = wordEnd + 1
+
+				if !yield(buf.String()) {
+					return
+				}
+			}
+		}
+	}
+}
+
 type token struct {
 	Text string
 	Type int
@@ -0,0 +1,99 @@
+package internal_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ttab/elephant-spell/internal"
+	"github.com/ttab/elephantine/test"
+)
+
+func TestNGramIterator(t *testing.T) {
+	test.EqualDiff(t,
+		[]string{
+			"A", "A B", "A B C",
+			"B", "B C", "B C D",
+			"C", "C D",
+			"D",
+		},
+		slices.Collect(internal.NGramIterator([]byte("A B C D"), 3)),
+		"yield all windows in document order")
+
+	test.EqualDiff(t,
+		[]string{"Hej", "Hej, världen", "världen"},
+		slices.Collect(internal.NGramIterator([]byte("Hej, världen!"), 2)),
+		"keep the separators between the words")
+}
+
+func TestPhraseIteratorWrap(t *testing.T) {
+	// Enough text to wrap the circular buffer of the iterator many times
+	// over for all the phrase lengths that we test.
+	filler := strings.Repeat("Det var en gång en kung, och han bodde på ett slott. ", 20)
+
+	const name = "Carl XVI Gustaf Bernadotte"
+
+	cases := map[string]string{
+		"start":  name + " är kung. " + filler,
+		"middle": filler + name + " är kung. " + filler,
+		"end":    filler + "Kungen heter " + name,
+	}
+
+	for position, text := range cases {
+		phrases := slices.Collect(
+			internal.PhraseIterator([]byte(text), 4))
+
+		test.Equal(t, true, slices.Contains(phrases, name),
+			"find the phrase at the %s of the text", position)
+	}
+
+	text := []byte(filler + name + ". " + filler)
+
+	for n := 1; n <= 5; n++ {
+		// The phrases are the same word sequences as the n-grams, but
+		// yielded ending at each word instead of starting at it.
+		want := slices.Collect(internal.NGramIterator(text, n))
+		got := slices.Collect(internal.PhraseIterator(text, n))
+
+		slices.Sort(want)
+		slices.Sort(got)
+
+		test.EqualDiff(t, want, got,
+			"yield all sequences of up to %d words", n)
+	}
+}
+
+func TestSentenceBoundedPhrases(t *testing.T) {
+	bounded := internal.Segmentation{
+		SentenceBounded: true,
+	}
+
+	text := []byte("Fången försökte rymma. Kanske i morgon!\nEller aldrig; vem vet?")
+
+	phrases := slices.Collect(bounded.Phrases(text, 3))
+	ngrams := slices.Collect(bounded.NGrams(text, 3))
+
+	for _, crossing := range []string{
+		"rymma. Kanske", "morgon!\nEller", "aldrig; vem",
+	} {
+		test.Equal(t, false, slices.Contains(phrases, crossing),
+			"no phrase %q across a sentence boundary", crossing)
+		test.Equal(t, false, slices.Contains(ngrams, crossing),
+			"no n-gram %q across a sentence boundary", crossing)
+	}
+
+	for _, within := range []string{
+		"Fången försökte rymma", "Kanske i morgon", "vem vet",
+	} {
+		test.Equal(t, true, slices.Contains(phrases, within),
+			"phrase %q within a sentence", within)
+		test.Equal(t, true, slices.Contains(ngrams, within),
+			"n-gram %q within a sentence", within)
+	}
+
+	var unbounded internal.Segmentation
+
+	test.Equal(t, true, slices.Contains(
+		slices.Collect(unbounded.Phrases(text, 3)), "rymma. Kanske"),
+		"phrases cross sentences by default")
+}
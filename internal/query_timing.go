@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ttab/elephant-spell/postgres"
+)
+
+// timedDB wraps a database connection or transaction and records how long
+// every query takes. Queries that take longer than the threshold are logged.
+// The time includes reading the rows of the result.
+type timedDB struct {
+	db        postgres.DBTX
+	logger    *slog.Logger
+	duration  *prometheus.HistogramVec
+	threshold time.Duration
+}
+
+// queries returns the queries for a connection pool or transaction, with
+// timing.
+func (a *Application) queries(db postgres.DBTX) *postgres.Queries {
+	return postgres.New(timedDB{
+		db:        db,
+		logger:    a.logger,
+		duration:  a.metrics.queryDuration,
+		threshold: a.p.SlowQueryThreshold,
+	})
+}
+
+func (t timedDB) Exec(
+	ctx context.Context, sql string, args ...any,
+) (pgconn.CommandTag, error) {
+	start := time.Now()
+
+	tag, err := t.db.Exec(ctx, sql, args...)
+
+	t.observe(sql, start)
+
+	return tag, err //nolint: wrapcheck
+}
+
+func (t timedDB) Query(
+	ctx context.Context, sql string, args ...any,
+) (pgx.Rows, error) {
+	start := time.Now()
+
+	rows, err := t.db.Query(ctx, sql, args...)
+	if err != nil {
+		t.observe(sql, start)
+
+		return nil, err //nolint: wrapcheck
+	}
+
+	return &timedRows{
+		Rows: rows,
+		done: func() { t.observe(sql, start) },
+	}, nil
+}
+
+func (t timedDB) QueryRow(
+	ctx context.Context, sql string, args ...any,
+) pgx.Row {
+	start := time.Now()
+
+	return timedRow{
+		row:  t.db.QueryRow(ctx, sql, args...),
+		done: func() { t.observe(sql, start) },
+	}
+}
+
+func (t timedDB) observe(sql string, start time.Time) {
+	name := queryName(sql)
+	duration := time.Since(start)
+
+	t.duration.WithLabelValues(name).Observe(duration.Seconds())
+
+	if t.threshold > 0 && duration > t.threshold {
+		t.logger.Warn("slow database query",
+			"query", name,
+			"duration", duration.String())
+	}
+}
+
+// queryName returns the name of a sqlc generated query from the name
+// comment at the start of the SQL.
+func queryName(sql string) string {
+	rest, ok := strings.CutPrefix(sql, "-- name: ")
+	if !ok {
+		return "unknown"
+	}
+
+	name, _, _ := strings.Cut(rest, " ")
+
+	return name
+}
+
+// timedRows records the query time when the rows are closed.
+type timedRows struct {
+	pgx.Rows
+
+	done   func()
+	closed bool
+}
+
+func (r *timedRows) Close() {
+	r.Rows.Close()
+
+	if !r.closed {
+		r.closed = true
+		r.done()
+	}
+}
+
+// timedRow records the query time when the row has been scanned.
+type timedRow struct {
+	row  pgx.Row
+	done func()
+}
+
+func (r timedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+
+	r.done()
+
+	return err //nolint: wrapcheck
+}
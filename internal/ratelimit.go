@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twitchtv/twirp"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// wordBurstSeconds is the number of seconds worth of words that a
+	// subject can check in a single burst.
+	wordBurstSeconds = 10
+	// limiterIdleTime is how long the limits of a subject are kept after
+	// its last check.
+	limiterIdleTime = 10 * time.Minute
+)
+
+// subjectLimiter rate limits checks per authenticated subject, both by the
+// number of requests and by the number of words that are checked.
+type subjectLimiter struct {
+	requests rate.Limit
+	words    rate.Limit
+
+	m         sync.Mutex
+	lastSweep time.Time
+	subjects  map[string]*subjectLimits
+}
+
+type subjectLimits struct {
+	requests *rate.Limiter
+	words    *rate.Limiter
+	lastSeen time.Time
+}
+
+// newSubjectLimiter creates a limiter that allows the given number of
+// requests and words per second for every subject. A zero limit is
+// unlimited.
+func newSubjectLimiter(requests float64, words float64) *subjectLimiter {
+	l := subjectLimiter{
+		requests: rate.Inf,
+		words:    rate.Inf,
+		subjects: make(map[string]*subjectLimits),
+	}
+
+	if requests > 0 {
+		l.requests = rate.Limit(requests)
+	}
+
+	if words > 0 {
+		l.words = rate.Limit(words)
+	}
+
+	return &l
+}
+
+// Allow checks and consumes the limits of the subject for a request that
+// checks the given texts. Returns a resource exhausted error if the subject
+// is over its limits.
+func (l *subjectLimiter) Allow(subject string, texts ...string) error {
+	limits := l.limits(subject)
+
+	if !limits.requests.Allow() {
+		return twirp.NewError(twirp.ResourceExhausted,
+			"too many requests, try again later")
+	}
+
+	words := countWords(texts)
+
+	if !limits.words.AllowN(time.Now(), words) {
+		return twirp.NewError(twirp.ResourceExhausted,
+			fmt.Sprintf("too many words (%d), try again later", words))
+	}
+
+	return nil
+}
+
+// WaitWords blocks until the subject is allowed to check the text, it's used
+// to throttle streamed checks instead of rejecting them midway.
+func (l *subjectLimiter) WaitWords(
+	ctx context.Context, subject string, text string,
+) error {
+	limits := l.limits(subject)
+
+	err := limits.words.WaitN(ctx, min(countWords([]string{text}),
+		limits.words.Burst()))
+	if err != nil {
+		return fmt.Errorf("wait for word limit: %w", err)
+	}
+
+	return nil
+}
+
+func (l *subjectLimiter) limits(subject string) *subjectLimits {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(l.lastSweep) > limiterIdleTime {
+		for s, limits := range l.subjects {
+			if now.Sub(limits.lastSeen) > limiterIdleTime {
+				delete(l.subjects, s)
+			}
+		}
+
+		l.lastSweep = now
+	}
+
+	limits, ok := l.subjects[subject]
+	if !ok {
+		limits = &subjectLimits{
+			requests: rate.NewLimiter(l.requests, burst(l.requests, 1)),
+			words: rate.NewLimiter(l.words,
+				burst(l.words, wordBurstSeconds)),
+		}
+
+		l.subjects[subject] = limits
+	}
+
+	limits.lastSeen = now
+
+	return limits
+}
+
+// burst returns the burst size for the given number of seconds of the limit.
+func burst(limit rate.Limit, seconds float64) int {
+	if limit == rate.Inf {
+		// The burst size is ignored for infinite limits.
+		return 0
+	}
+
+	return max(1, int(float64(limit)*seconds))
+}
+
+// countWords approximates the number of words in the texts by splitting
+// them on white space, which is good enough for rate limiting.
+func countWords(texts []string) int {
+	var n int
+
+	for _, t := range texts {
+		n += len(strings.Fields(t))
+	}
+
+	return n
+}
@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/ttab/elephant-spell/postgres"
+	"github.com/ttab/elephantine/pg"
+)
+
+// entryReconcileInterval is how often the in-memory entries are compared
+// with the database to catch updates that we never got a notification for.
+const entryReconcileInterval = 5 * time.Minute
+
+// reconcileEntries compares a fingerprint of the entries in the database, the
+// number of live entries and the time of the last update per language, with
+// the loaded entries. Entries that have changed since the last
+// reconciliation, or all entries if the numbers don't add up, are compared
+// one by one and the ones that are out of sync are reloaded.
+//
+// Must only be called from the entry updater, so that the reloads can't race
+// with the regular updates.
+func (a *Application) reconcileEntries(ctx context.Context) error {
+	stats, err := a.q.GetDictionaryStats(ctx)
+	if err != nil {
+		return fmt.Errorf("count entries in database: %w", err)
+	}
+
+	stored := make(map[string]int64, len(stats))
+
+	for _, row := range stats {
+		if !isLiveStatus(row.Status) {
+			continue
+		}
+
+		stored[row.Language] += row.Entries
+	}
+
+	updates, err := a.q.GetLastUpdated(ctx)
+	if err != nil {
+		return fmt.Errorf("read last updates from database: %w", err)
+	}
+
+	lastUpdated := make(map[string]time.Time, len(updates))
+
+	for _, row := range updates {
+		lastUpdated[row.Language] = row.LastUpdated.Time
+	}
+
+	a.m.RLock()
+
+	synced := make(map[string]time.Time, len(a.syncedAt))
+	loaded := make(map[string]int64, len(a.checkers))
+
+	for language := range a.checkers {
+		synced[language] = a.syncedAt[language]
+		loaded[language] = int64(len(a.loaded[language]))
+	}
+
+	a.m.RUnlock()
+
+	for language, syncedAt := range synced {
+		var stale map[string]bool
+
+		if lastUpdated[language].After(syncedAt) {
+			stale, err = a.changedStaleEntries(ctx, language, syncedAt)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(stale) == 0 && stored[language] != loaded[language] {
+			stale, err = a.allStaleEntries(ctx, language)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(stale) > 0 {
+			a.logger.WarnContext(ctx, "reloading entries that were out of sync",
+				"language", language,
+				"entries", len(stale))
+
+			a.metrics.entriesReconciled.WithLabelValues(language).Add(
+				float64(len(stale)))
+
+			err := a.applyEntryUpdates(ctx, language, stale)
+			if err != nil {
+				return fmt.Errorf("reload %s entries: %w", language, err)
+			}
+
+			for text, deleted := range stale {
+				a.queueWebhook(EntryUpdateNotification{
+					Language: language,
+					Text:     text,
+					Deleted:  deleted,
+				})
+			}
+		}
+
+		a.m.Lock()
+
+		if lastUpdated[language].After(a.syncedAt[language]) {
+			a.syncedAt[language] = lastUpdated[language]
+		}
+
+		a.m.Unlock()
+	}
+
+	return nil
+}
+
+// changedStaleEntries returns the entries of a language that have been
+// changed since the given time and that are out of sync with the loaded
+// entries. The value is true for deleted entries.
+func (a *Application) changedStaleEntries(
+	ctx context.Context, language string, since time.Time,
+) (map[string]bool, error) {
+	rows, err := a.q.ListChangedEntries(ctx, postgres.ListChangedEntriesParams{
+		Language: pg.Text(language),
+		Since: pgtype.Timestamptz{
+			Time:  since.Add(-listenerResyncMargin),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list changed %s entries: %w", language, err)
+	}
+
+	stale := make(map[string]bool)
+
+	a.m.RLock()
+	defer a.m.RUnlock()
+
+	for _, row := range rows {
+		live := !row.Deleted && isLiveStatus(row.Status)
+
+		if !a.entryInSync(language, row.Entry, live, row.UpdatedAt.Time) {
+			stale[row.Entry] = row.Deleted
+		}
+	}
+
+	return stale, nil
+}
+
+// allStaleEntries compares all entries of a language with the loaded entries
+// and returns the ones that are out of sync. The value is true for entries
+// that have been deleted.
+func (a *Application) allStaleEntries(
+	ctx context.Context, language string,
+) (map[string]bool, error) {
+	rows, err := a.readLanguageEntries(ctx, language)
+	if err != nil {
+		return nil, err
+	}
+
+	stale := make(map[string]bool)
+	stored := make(map[string]bool, len(rows))
+
+	a.m.RLock()
+	defer a.m.RUnlock()
+
+	for _, row := range rows {
+		stored[row.Entry] = true
+
+		live := isLiveStatus(row.Status)
+
+		if !a.entryInSync(language, row.Entry, live, row.UpdatedAt.Time) {
+			stale[row.Entry] = false
+		}
+	}
+
+	for text := range a.loaded[language] {
+		if !stored[text] {
+			stale[text] = true
+		}
+	}
+
+	return stale, nil
+}
+
+// entryInSync returns true if the loaded state of an entry matches the
+// database, live entries must be loaded with the same update time. The
+// caller must hold the read lock.
+func (a *Application) entryInSync(
+	language string, text string, live bool, updated time.Time,
+) bool {
+	loaded := a.loaded[language][text]
+	if !live || !loaded {
+		return live == loaded
+	}
+
+	phrases, ok := a.phrases[language]
+	if !ok {
+		return false
+	}
+
+	p, ok := phrases.Get(text).(*phrase)
+
+	return ok && p.Text == text && p.UpdatedAt.Equal(updated)
+}
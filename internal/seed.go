@@ -0,0 +1,250 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ttab/elephant-spell/postgres"
+	"github.com/ttab/elephantine/pg"
+	"gopkg.in/yaml.v3"
+)
+
+// seedUser is recorded as the author of the entries that are written from
+// the seed directory.
+const seedUser = "seed"
+
+// seedEntry is the YAML representation of an entry in a seed file. The
+// language is given by the name of the file.
+type seedEntry struct {
+	Text           string   `yaml:"text"`
+	Status         string   `yaml:"status"`
+	Description    string   `yaml:"description"`
+	CommonMistakes []string `yaml:"common_mistakes"`
+	MatchMode      string   `yaml:"match_mode"`
+	Level          string   `yaml:"level"`
+	Enforced       *bool    `yaml:"enforced"`
+	AffixModel     *string  `yaml:"affix_model"`
+	Forms          []string `yaml:"forms"`
+}
+
+// readSeedFiles reads the entries of all seed files in the directory. Seed
+// files are named after the language, f.ex. "sv-se.yaml", and contain a list
+// of entries. Entries without a status are approved.
+func readSeedFiles(dir string) ([]entryRecord, error) {
+	var files []string
+
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("list seed files: %w", err)
+		}
+
+		files = append(files, matches...)
+	}
+
+	var records []entryRecord
+
+	for _, file := range files {
+		entries, err := readSeedFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read seed file %q: %w",
+				filepath.Base(file), err)
+		}
+
+		name := filepath.Base(file)
+		language := NormalizeLanguage(
+			strings.TrimSuffix(name, filepath.Ext(name)))
+
+		for _, e := range entries {
+			record := entryRecord{
+				Language:       language,
+				Text:           e.Text,
+				Status:         e.Status,
+				Description:    e.Description,
+				CommonMistakes: e.CommonMistakes,
+				MatchMode:      e.MatchMode,
+				Level:          e.Level,
+				Enforced:       e.Enforced,
+				AffixModel:     e.AffixModel,
+				Forms:          e.Forms,
+			}
+
+			if record.Status == "" {
+				record.Status = StatusApproved
+			}
+
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+func readSeedFile(name string) (_ []seedEntry, outErr error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			outErr = errors.Join(outErr, fmt.Errorf("close file: %w", err))
+		}
+	}()
+
+	dec := yaml.NewDecoder(f)
+
+	// Catch misspelled field names instead of silently dropping them.
+	dec.KnownFields(true)
+
+	var entries []seedEntry
+
+	err = dec.Decode(&entries)
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("decode entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// seedEntries writes the entries in the seed directory that are missing from
+// the database, or that differ from the stored entries, and applies them to
+// the in-memory state. Entries that already match are left alone, so seeding
+// again after a restart doesn't write anything. Entries that have been deleted
+// are left deleted, so that an operator can remove a seed entry.
+func (a *Application) seedEntries(ctx context.Context) (outErr error) {
+	records, err := readSeedFiles(a.p.SeedDir)
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	params := postgres.GetEntriesParams{
+		Languages:      make([]string, len(records)),
+		Entries:        make([]string, len(records)),
+		IncludeDeleted: true,
+	}
+
+	for i := range records {
+		e := &records[i]
+
+		err := a.prepareEntryRecord(
+			fmt.Sprintf("%s entry %q", e.Language, e.Text), e)
+		if err != nil {
+			return fmt.Errorf("invalid seed entry: %w", err)
+		}
+
+		params.Languages[i] = e.Language
+		params.Entries[i] = e.Text
+	}
+
+	rows, err := a.q.GetEntries(ctx, params)
+	if err != nil {
+		return fmt.Errorf("read entries from database: %w", err)
+	}
+
+	type entryKey struct {
+		Language string
+		Text     string
+	}
+
+	existing := make(map[entryKey]postgres.Entry, len(rows))
+
+	for _, row := range rows {
+		existing[entryKey{row.Language, row.Entry}] = row
+	}
+
+	var (
+		changed []entryRecord
+		deleted int
+	)
+
+	for _, e := range records {
+		row, ok := existing[entryKey{e.Language, e.Text}]
+
+		switch {
+		case ok && row.DeletedAt.Valid:
+			deleted++
+		case ok && e.Matches(row):
+		default:
+			changed = append(changed, e)
+		}
+	}
+
+	if len(changed) == 0 {
+		a.logger.InfoContext(ctx, "seed entries are up to date",
+			"entries", len(records),
+			"deleted", deleted)
+
+		return nil
+	}
+
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("start transaction: %w", err)
+	}
+
+	defer pg.Rollback(tx, &outErr)
+
+	q := a.queries(tx)
+
+	updated := make(map[string][]string)
+
+	for _, e := range changed {
+		err := setEntryWithHistory(ctx, q, e.SetEntryParams(seedUser))
+		if err != nil {
+			return fmt.Errorf("write %s entry %q: %w",
+				e.Language, e.Text, err)
+		}
+
+		updated[e.Language] = append(updated[e.Language], e.Text)
+	}
+
+	// Let other instances know about the changes.
+	for language, texts := range updated {
+		err := notifyEntriesUpdated(ctx, q, language, texts, false)
+		if err != nil {
+			return fmt.Errorf("send notification: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("commit changes: %w", err)
+	}
+
+	// Apply the changes right away so that we don't report that we're
+	// ready before the seed entries are in use.
+	for language, texts := range updated {
+		updates := make(map[string]bool, len(texts))
+
+		for _, text := range texts {
+			updates[text] = false
+		}
+
+		err := a.applyEntryUpdates(ctx, language, updates)
+		if err != nil {
+			return fmt.Errorf("apply %s entries: %w", language, err)
+		}
+	}
+
+	a.logger.InfoContext(ctx, "wrote seed entries",
+		"entries", len(records),
+		"written", len(changed),
+		"deleted", deleted)
+
+	return nil
+}
@@ -0,0 +1,338 @@
+package internal
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blevesearch/segment"
+)
+
+// Segmentation is language specific configuration for how a text is split
+// into words. The zero value gives plain unicode word segmentation.
+type Segmentation struct {
+	// WordInternal are runes that should be treated as a part of the word
+	// when they occur between two letters, f.ex. the hyphen in "e-post".
+	// Apostrophes and colons are already treated as word internal by the
+	// unicode word segmentation rules.
+	WordInternal []rune
+	// SentenceBounded stops phrases and n-grams at strong punctuation
+	// (. ! ? ;) and line breaks, so that they stay within a sentence or
+	// clause.
+	SentenceBounded bool
+}
+
+// Tokens splits a text into tokens using unicode word segmentation, and then
+// joins letter tokens that are separated by a word internal rune. A letter
+// token can also be joined with a following number, f.ex. "COVID-19".
+func (s Segmentation) Tokens(text []byte) func(yield func(t token) bool) {
+	segmenter := segment.NewWordSegmenter(bytes.NewReader(text))
+
+	return func(yield func(t token) bool) {
+		// A pending letter token, and possibly a word internal
+		// separator, that we can't emit until we know what follows.
+		var pending []token
+
+		flush := func() bool {
+			for _, t := range pending {
+				if !yield(t) {
+					return false
+				}
+			}
+
+			pending = pending[0:0]
+
+			return true
+		}
+
+		for segmenter.Segment() {
+			t := token{
+				Text: segmenter.Text(),
+				Type: segmenter.Type(),
+			}
+
+			switch {
+			case len(pending) == 1 && s.isWordInternal(t):
+				pending = append(pending, t)
+
+				continue
+			case len(pending) == 2 && (t.Type == segment.Letter ||
+				t.Type == segment.Number):
+				pending[0].Text += pending[1].Text + t.Text
+				pending = pending[0:1]
+
+				continue
+			}
+
+			if !flush() {
+				return
+			}
+
+			if t.Type == segment.Letter {
+				pending = append(pending, t)
+
+				continue
+			}
+
+			if !yield(t) {
+				return
+			}
+		}
+
+		flush()
+	}
+}
+
+// PhraseLength returns the number of words in the phrase, which is the
+// phrase length needed for Phrases to yield it.
+func (s Segmentation) PhraseLength(phrase string) int {
+	var n int
+
+	for t := range s.Tokens([]byte(phrase)) {
+		if t.Type == segment.Letter {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Standalone returns the occurrences of the phrase that don't have a word
+// directly before or after them. Spaces are skipped when looking for the
+// neighbouring tokens, so "Belarus" stands alone in "Belarus, Ukraina" but not
+// in "i Belarus". Line breaks aren't skipped, a phrase on a line of its own
+// stands alone.
+func (s Segmentation) Standalone(text []byte, phrase string) []TextRange {
+	tokens := slices.Collect(s.Tokens(text))
+	phraseTokens := slices.Collect(s.Tokens([]byte(phrase)))
+
+	if len(phraseTokens) == 0 {
+		return nil
+	}
+
+	// The byte and code point offsets of the start of every token, and
+	// of the end of the text.
+	byteOffsets := make([]int, len(tokens)+1)
+	runeOffsets := make([]int, len(tokens)+1)
+
+	for i, t := range tokens {
+		byteOffsets[i+1] = byteOffsets[i] + len(t.Text)
+		runeOffsets[i+1] = runeOffsets[i] + utf8.RuneCountInString(t.Text)
+	}
+
+	var found []TextRange
+
+	for i := 0; i+len(phraseTokens) <= len(tokens); i++ {
+		match := slices.EqualFunc(
+			tokens[i:i+len(phraseTokens)], phraseTokens,
+			func(a token, b token) bool {
+				return a.Text == b.Text
+			})
+		if !match {
+			continue
+		}
+
+		before := i - 1
+		for before >= 0 && isSpace(tokens[before]) {
+			before--
+		}
+
+		after := i + len(phraseTokens)
+		for after < len(tokens) && isSpace(tokens[after]) {
+			after++
+		}
+
+		if before >= 0 && tokens[before].Type == segment.Letter {
+			continue
+		}
+
+		if after < len(tokens) && tokens[after].Type == segment.Letter {
+			continue
+		}
+
+		end := i + len(phraseTokens)
+
+		found = append(found, TextRange{
+			Start:     runeOffsets[i],
+			End:       runeOffsets[end],
+			StartByte: byteOffsets[i],
+			EndByte:   byteOffsets[end],
+		})
+	}
+
+	return found
+}
+
+// isSpace returns true for tokens that only are whitespace without a line
+// break.
+func isSpace(t token) bool {
+	return t.Type != segment.Letter && strings.TrimSpace(t.Text) == "" &&
+		!strings.ContainsAny(t.Text, "\r\n")
+}
+
+// blankRanges returns a copy of the text where the ranges have been replaced
+// with spaces, which keeps the offsets of the rest of the text.
+func blankRanges(text []byte, ranges []TextRange) []byte {
+	blanked := slices.Clone(text)
+
+	for _, r := range ranges {
+		for i := r.StartByte; i < r.EndByte; i++ {
+			blanked[i] = ' '
+		}
+	}
+
+	return blanked
+}
+
+// Speller checks the spelling of single words, it's implemented by the
+// hunspell checkers.
+type Speller interface {
+	Spell(word string) bool
+}
+
+// TextRange is the range of a phrase in a text, in unicode code points and in
+// bytes. The ends are exclusive.
+type TextRange struct {
+	Start     int `json:"start"`
+	End       int `json:"end"`
+	StartByte int `json:"start_byte"`
+	EndByte   int `json:"end_byte"`
+}
+
+// Locate finds all occurrences of the phrases in the text. An occurrence must
+// not have a letter or digit directly before or after it, so "Belarus" isn't
+// found in "Belarusiska". Occurrences don't have to line up with the tokens,
+// so a flagged part of a hyphenated word, like "resolutionen" in
+// "FN-resolutionen", or a word that had punctuation trimmed from it is found
+// as well.
+func (s Segmentation) Locate(text []byte, phrases []string) map[string][]TextRange {
+	ranges := make(map[string][]TextRange, len(phrases))
+
+	var positions []int
+
+	for _, phrase := range phrases {
+		if _, done := ranges[phrase]; done || phrase == "" {
+			continue
+		}
+
+		found := []TextRange{}
+		needle := []byte(phrase)
+
+		for offset := 0; offset < len(text); {
+			i := bytes.Index(text[offset:], needle)
+			if i == -1 {
+				break
+			}
+
+			start := offset + i
+			end := start + len(needle)
+
+			if isWordEdge(text, start, end) {
+				found = append(found, TextRange{
+					StartByte: start,
+					EndByte:   end,
+				})
+
+				positions = append(positions, start, end)
+			}
+
+			_, size := utf8.DecodeRune(text[start:])
+			offset = start + size
+		}
+
+		ranges[phrase] = found
+	}
+
+	// Convert the byte offsets to code points in a single pass over the
+	// text.
+	slices.Sort(positions)
+
+	runeOffsets := make(map[int]int, len(positions))
+
+	var offset, runes int
+
+	for _, pos := range slices.Compact(positions) {
+		runes += utf8.RuneCount(text[offset:pos])
+		offset = pos
+
+		runeOffsets[pos] = runes
+	}
+
+	for _, found := range ranges {
+		for i := range found {
+			found[i].Start = runeOffsets[found[i].StartByte]
+			found[i].End = runeOffsets[found[i].EndByte]
+		}
+	}
+
+	return ranges
+}
+
+// isWordEdge returns true if the range of the text isn't directly preceded or
+// followed by a letter or a digit.
+func isWordEdge(text []byte, start int, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRune(text[:start])
+		if isWordRune(r) {
+			return false
+		}
+	}
+
+	if end < len(text) {
+		r, _ := utf8.DecodeRune(text[end:])
+		if isWordRune(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+// MisspelledParts checks a word that hunspell didn't accept as a whole part by
+// part, where the parts are separated by word internal runes. Compounds like
+// "FN-resolutionen" are often unknown to the dictionary even though both "FN"
+// and "resolutionen" are. Returns the parts that are misspelled, a word
+// without word internal runes is returned as is.
+func (s Segmentation) MisspelledParts(sp Speller, word string) []string {
+	parts := strings.FieldsFunc(word, func(r rune) bool {
+		return slices.Contains(s.WordInternal, r)
+	})
+
+	if len(parts) < 2 {
+		return []string{word}
+	}
+
+	var misspelled []string
+
+	for _, part := range parts {
+		if sp.Spell(part) || slices.Contains(misspelled, part) {
+			continue
+		}
+
+		misspelled = append(misspelled, part)
+	}
+
+	return misspelled
+}
+
+// isBoundary returns true if phrases shouldn't span the token.
+func (s Segmentation) isBoundary(t token) bool {
+	return s.SentenceBounded && t.Type != segment.Letter &&
+		strings.ContainsAny(t.Text, ".!?;\n")
+}
+
+func (s Segmentation) isWordInternal(t token) bool {
+	if len(s.WordInternal) == 0 || utf8.RuneCountInString(t.Text) != 1 {
+		return false
+	}
+
+	r, _ := utf8.DecodeRuneInString(t.Text)
+
+	return slices.Contains(s.WordInternal, r)
+}
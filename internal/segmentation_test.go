@@ -0,0 +1,215 @@
+package internal_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ttab/elephant-spell/internal"
+	"github.com/ttab/elephantine/test"
+)
+
+func TestSegmentationWordInternal(t *testing.T) {
+	hyphen := internal.Segmentation{
+		WordInternal: []rune{'-'},
+	}
+
+	text := []byte("Skicka e-post om FN-resolutionen - eller -inte.")
+
+	test.EqualDiff(t,
+		[]string{"Skicka", "e", "post", "om", "FN", "resolutionen", "eller", "inte"},
+		slices.Collect(internal.Words(text)),
+		"split on hyphens by default")
+
+	test.EqualDiff(t,
+		[]string{"Skicka", "e-post", "om", "FN-resolutionen", "eller", "inte"},
+		slices.Collect(hyphen.Words(text)),
+		"keep hyphenated words together")
+
+	test.EqualDiff(t,
+		[]string{"Vaccin", "mot", "COVID-19"},
+		slices.Collect(hyphen.Words([]byte("Vaccin mot COVID-19."))),
+		"keep letters and a trailing number together")
+
+	test.EqualDiff(t,
+		[]string{"Skicka", "e-post", "Skicka e-post"},
+		slices.Collect(hyphen.Phrases([]byte("Skicka e-post"), 3)),
+		"keep hyphenated words together in phrases")
+}
+
+func TestPhraseLength(t *testing.T) {
+	var seg internal.Segmentation
+
+	const name = "Carl XVI Gustaf Bernadotte"
+
+	length := seg.PhraseLength(name)
+
+	test.Equal(t, 4, length, "count the words in %q", name)
+
+	text := []byte("Kungen heter Carl XVI Gustaf Bernadotte.")
+
+	test.Equal(t, false,
+		slices.Contains(slices.Collect(seg.Phrases(text, 3)), name),
+		"a three word window should miss the name")
+	test.Equal(t, true,
+		slices.Contains(slices.Collect(seg.Phrases(text, length)), name),
+		"a window of the phrase length should find the name")
+}
+
+type fakeSpeller map[string]bool
+
+func (f fakeSpeller) Spell(word string) bool {
+	return f[word]
+}
+
+func TestMisspelledParts(t *testing.T) {
+	hyphen := internal.Segmentation{
+		WordInternal: []rune{'-'},
+	}
+
+	speller := fakeSpeller{
+		"FN":           true,
+		"resolutionen": true,
+		"e":            true,
+		"post":         true,
+		"Stockholm":    true,
+		"Göteborg":     true,
+	}
+
+	test.EqualDiff(t,
+		[]string(nil),
+		hyphen.MisspelledParts(speller, "FN-resolutionen"),
+		"accept a hyphenated proper noun with known parts")
+
+	test.EqualDiff(t,
+		[]string(nil),
+		hyphen.MisspelledParts(speller, "Stockholm-Göteborg"),
+		"accept hyphenated proper nouns")
+
+	test.EqualDiff(t,
+		[]string{"psot"},
+		hyphen.MisspelledParts(speller, "e-psot"),
+		"report the misspelled part of a prefix compound")
+
+	test.EqualDiff(t,
+		[]string{"rätstavad"},
+		hyphen.MisspelledParts(speller, "rätstavad"),
+		"return a word without parts as is")
+
+	test.EqualDiff(t,
+		[]string{"FN-resolutionen"},
+		internal.Segmentation{}.MisspelledParts(speller, "FN-resolutionen"),
+		"only split on word internal runes")
+}
+
+func TestStandalone(t *testing.T) {
+	var seg internal.Segmentation
+
+	cases := []struct {
+		Text   string
+		Phrase string
+		Want   []internal.TextRange
+	}{
+		{
+			Text:   "Belarus, Ukraina och Polen",
+			Phrase: "Belarus",
+			Want: []internal.TextRange{{
+				Start: 0, End: 7,
+				StartByte: 0, EndByte: 7,
+			}},
+		},
+		{
+			Text:   "Mötet i Belarus.",
+			Phrase: "Belarus",
+		},
+		{
+			Text:   "New York Times rapporterar",
+			Phrase: "New York",
+		},
+		{
+			Text:   "Resmål: New York.",
+			Phrase: "New York",
+			Want: []internal.TextRange{{
+				Start: 8, End: 16,
+				StartByte: 9, EndByte: 17,
+			}},
+		},
+		{
+			// Only the occurrence on a line of its own stands
+			// alone.
+			Text:   "I Belarus och\n\nBelarus\n",
+			Phrase: "Belarus",
+			Want: []internal.TextRange{{
+				Start: 15, End: 22,
+				StartByte: 15, EndByte: 22,
+			}},
+		},
+		{
+			Text:   "Belarus\ni Belarus",
+			Phrase: "Belarus",
+			Want: []internal.TextRange{{
+				Start: 0, End: 7,
+				StartByte: 0, EndByte: 7,
+			}},
+		},
+		{
+			Text:   "Belarusiska myndigheter",
+			Phrase: "Belarus",
+		},
+		{
+			Phrase: "Belarus",
+		},
+		{
+			Text:   "\"New York\" – 12 gånger",
+			Phrase: "New York",
+			Want: []internal.TextRange{{
+				Start: 1, End: 9,
+				StartByte: 1, EndByte: 9,
+			}},
+		},
+		{
+			Text:   "Han reste till New  York i går",
+			Phrase: "New  York",
+		},
+	}
+
+	for _, c := range cases {
+		got := seg.Standalone([]byte(c.Text), c.Phrase)
+
+		test.EqualDiff(t, c.Want, got,
+			"standalone %q in %q", c.Phrase, c.Text)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	var seg internal.Segmentation
+
+	text := []byte("Mohammar Khadaffi träffade Khadaffi i Tripoli. Khadaffis tält.")
+
+	got := seg.Locate(text, []string{
+		"Mohammar Khadaffi", "Khadaffi", "tält", "Benghazi",
+	})
+
+	test.EqualDiff(t, map[string][]internal.TextRange{
+		"Mohammar Khadaffi": {{Start: 0, End: 17, StartByte: 0, EndByte: 17}},
+		"Khadaffi": {
+			{Start: 9, End: 17, StartByte: 9, EndByte: 17},
+			{Start: 27, End: 35, StartByte: 28, EndByte: 36},
+		},
+		"tält":     {{Start: 57, End: 61, StartByte: 58, EndByte: 63}},
+		"Benghazi": {},
+	}, got, "locate the phrases")
+
+	hyphen := internal.Segmentation{
+		WordInternal: []rune{'-'},
+	}
+
+	text = []byte("Enligt FN-resolutionen, \"rsolutionen\" och e-posten.")
+
+	got = hyphen.Locate(text, []string{"resolutionen", "rsolutionen", "post"})
+
+	test.EqualDiff(t, map[string][]internal.TextRange{
+		"resolutionen": {{Start: 10, End: 22, StartByte: 10, EndByte: 22}},
+		"rsolutionen":  {{Start: 25, End: 36, StartByte: 25, EndByte: 36}},
+		"post":         {},
+	}, got, "locate parts of hyphenated words and trimmed words")
+}
@@ -6,23 +6,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/fs"
 	"log/slog"
-	"os"
-	"path/filepath"
+	"maps"
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/blevesearch/segment"
 	"github.com/dghubble/trie"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/ttab/elephant-api/spell"
-	"github.com/ttab/elephant-spell/dictionaries"
 	"github.com/ttab/elephant-spell/hunspell"
 	"github.com/ttab/elephant-spell/postgres"
 	"github.com/ttab/elephantine"
@@ -32,9 +30,61 @@ import (
 )
 
 const (
-	ScopeSpellcheckWrite = "spell_write"
+	ScopeSpellcheckWrite   = "spell_write"
+	ScopeSpellcheckAdmin   = "spell_admin"
+	ScopeSpellcheckPropose = "spell_propose"
 )
 
+// Entry statuses with a special meaning. Entries that are pending review or
+// have been rejected aren't used when checking texts.
+const (
+	StatusApproved = "approved"
+	StatusPending  = "pending"
+	StatusRejected = "rejected"
+)
+
+// isLiveStatus returns true if entries with the status should be used when
+// checking texts.
+func isLiveStatus(status string) bool {
+	return status != StatusPending && status != StatusRejected
+}
+
+// Entry match modes. A sequence entry matches wherever its words occur in a
+// text, a whole entry only matches when it stands alone, without other words
+// next to it.
+const (
+	MatchModeSequence = "sequence"
+	MatchModeWhole    = "whole"
+)
+
+// validateMatchMode checks that the match mode is known, an empty match mode
+// keeps the mode of an existing entry.
+func validateMatchMode(field string, mode string) error {
+	switch mode {
+	case "", MatchModeSequence, MatchModeWhole:
+		return nil
+	}
+
+	return twirp.InvalidArgumentError(field, fmt.Sprintf(
+		"must be %q or %q", MatchModeSequence, MatchModeWhole))
+}
+
+// LanguageWriteScope returns the scope that grants write access to the
+// dictionary of a single language, f.ex. "spell_write:sv-se".
+func LanguageWriteScope(language string) string {
+	return ScopeSpellcheckWrite + ":" + language
+}
+
+// requireWriteAccess checks that the client is allowed to write to the
+// dictionary for the given language, either through the blanket write scope
+// or the language specific one.
+func requireWriteAccess(
+	ctx context.Context, language string,
+) (*elephantine.AuthInfo, error) {
+	return elephantine.RequireAnyScope(ctx, //nolint: wrapcheck
+		ScopeSpellcheckWrite, LanguageWriteScope(language))
+}
+
 type NotifyChannel string
 
 const (
@@ -53,100 +103,175 @@ type Parameters struct {
 	Database       *pgxpool.Pool
 	AuthInfoParser elephantine.AuthInfoParser
 	Registerer     prometheus.Registerer
+	// Segmentation is the language specific segmentation configuration,
+	// keyed by normalized language code. Every language must have a
+	// dictionary.
+	Segmentation map[string]Segmentation
+	// CheckerPoolSize is the number of hunspell checkers that are created
+	// per language. Each checker can run one check at a time, and holds
+	// its own copy of the dictionary in memory. Defaults to 1.
+	CheckerPoolSize int
+	// MaxSuggestions is the maximum number of suggestions that are
+	// returned per misspelled word. Zero is unlimited.
+	MaxSuggestions int
+	// MaxHunspellSuggest caps the number of hunspell suggestions that are
+	// used per misspelled word, before suggestions from custom entries
+	// are added and MaxSuggestions is applied. Zero is unlimited.
+	MaxHunspellSuggest int
+	// SeedDir is an optional directory with YAML files of entries, one
+	// file per language, that are written to the database at startup if
+	// they're missing or have been changed.
+	SeedDir string
+	// DictionaryDir is a directory with .aff and .dic files to load in
+	// addition to the embedded dictionaries. A dictionary in the directory
+	// replaces the embedded dictionary for the same language.
+	DictionaryDir string
+	// WebhookURL is an optional URL that entry update notifications are
+	// posted to once the changes have been applied.
+	WebhookURL string
+	// SentenceBoundedPhrases stops custom entry phrases from matching
+	// across sentence boundaries in all languages. Phrases that contain
+	// strong punctuation, like "St. Petersburg", won't match.
+	SentenceBoundedPhrases bool
+	// TextRequestsPerSecond is the number of text checks that every
+	// authenticated subject is allowed per second. Zero is unlimited.
+	TextRequestsPerSecond float64
+	// TextWordsPerSecond is the number of words that every authenticated
+	// subject is allowed to check per second. Zero is unlimited.
+	TextWordsPerSecond float64
+	// MaxTextBytes is the maximum total size of the texts in a check
+	// request. Zero is unlimited.
+	MaxTextBytes int
+	// MaxTextItems is the maximum number of texts in a check request.
+	// Zero is unlimited.
+	MaxTextItems int
+	// RecordSpellingGaps counts the misspelled words that hunspell has
+	// no suggestions for, so that curators can find words that are
+	// missing from the dictionary.
+	RecordSpellingGaps bool
+	// SlowQueryThreshold is the duration after which a database query is
+	// logged as slow. Zero disables the logging.
+	SlowQueryThreshold time.Duration
+	// SuggestionCacheSize is the number of misspelled words to cache the
+	// hunspell suggestions for. Zero disables the cache.
+	SuggestionCacheSize int
 }
 
 func NewApplication(
 	ctx context.Context, p Parameters,
 ) (_ *Application, outErr error) {
-	// We need to set up a directory with our dictionaries so that hunspell
-	// can load them.
-	tmpDir, err := os.MkdirTemp("", "spell-dicts-*")
+	checkers, err := loadCheckers(p.Logger,
+		dictionarySources(p.DictionaryDir), p.CheckerPoolSize)
 	if err != nil {
-		return nil, fmt.Errorf("create dictionary directory: %w", err)
+		return nil, err
 	}
 
 	defer func() {
-		err := os.RemoveAll(tmpDir)
-		if err != nil {
-			outErr = errors.Join(outErr, fmt.Errorf(
-				"clean up temporary dictionary files: %w", err))
+		if outErr == nil {
+			return
 		}
-	}()
 
-	dictFS := dictionaries.GetFS()
+		for _, checker := range checkers {
+			_ = checker.Close()
+		}
+	}()
 
-	dictFiles, err := dictFS.ReadDir(".")
-	if err != nil {
-		return nil, fmt.Errorf("list embedded dictionaries: %w", err)
+	for language := range p.Segmentation {
+		_, ok := checkers[language]
+		if !ok {
+			return nil, fmt.Errorf(
+				"segmentation configured for unsupported language %q, supported languages are: %s",
+				language, strings.Join(slices.Sorted(maps.Keys(checkers)), ", "))
+		}
 	}
 
-	var supportedLanguages []string
-
-	// Copy embedded dictionaries to the temp dir.
-	for _, file := range dictFiles {
-		name := filepath.Base(file.Name())
+	phrases := make(map[string]*trie.RuneTrie, len(checkers))
 
-		data, err := fs.ReadFile(dictFS, file.Name())
-		if err != nil {
-			return nil, fmt.Errorf("read embedded dictionary %q: %w",
-				name, err)
-		}
-
-		err = os.WriteFile(filepath.Join(tmpDir, name), data, 0o600)
-		if err != nil {
-			return nil, fmt.Errorf("copy embedded dictionary %q: %w",
-				name, err)
-		}
+	for code := range checkers {
+		phrases[code] = trie.NewRuneTrie()
+	}
 
-		language, ok := strings.CutSuffix(name, ".dic")
-		if ok {
-			supportedLanguages = append(supportedLanguages, language)
-		}
+	m, err := newMetrics(p.Registerer)
+	if err != nil {
+		return nil, fmt.Errorf("set up metrics: %w", err)
 	}
 
-	checkers := make(map[string]*hunspell.Checker, len(supportedLanguages))
-	phrases := make(map[string]*trie.RuneTrie)
+	limiter := newSubjectLimiter(
+		p.TextRequestsPerSecond, p.TextWordsPerSecond)
 
-	// Instantiate one hunspell checker per language.
-	for _, lang := range supportedLanguages {
-		checker, err := hunspell.NewChecker(
-			filepath.Join(tmpDir, lang+".aff"),
-			filepath.Join(tmpDir, lang+".dic"),
-		)
-		if err != nil {
-			return nil, fmt.Errorf("create hunspell checker for %q: %w",
-				lang, err)
-		}
+	app := Application{
+		p:              p,
+		logger:         p.Logger,
+		db:             p.Database,
+		metrics:        m,
+		maxSuggestions: max(p.MaxSuggestions, 0),
+		limiter:        limiter,
+		suggestions:    NewSuggestionCache(p.SuggestionCacheSize),
+		textLimits: TextLimits{
+			MaxBytes: p.MaxTextBytes,
+			MaxItems: p.MaxTextItems,
+		},
+		checkers:     checkers,
+		phrases:      phrases,
+		loaded:       make(map[string]map[string]bool, len(checkers)),
+		phraseLength: make(map[string]int, len(checkers)),
+		syncedAt:     make(map[string]time.Time, len(checkers)),
+	}
 
-		// Convert from sv_SE to sv-se.
-		code := strings.ToLower(strings.Replace(lang, "_", "-", 1))
+	app.q = app.queries(p.Database)
 
-		checkers[code] = checker
-		phrases[code] = trie.NewRuneTrie()
+	if p.WebhookURL != "" {
+		app.webhooks = make(chan EntryUpdateNotification, webhookQueueSize)
 	}
 
-	app := Application{
-		p:        p,
-		logger:   p.Logger,
-		db:       p.Database,
-		q:        postgres.New(p.Database),
-		checkers: checkers,
-		phrases:  phrases,
+	if p.RecordSpellingGaps {
+		app.gaps = newGapRecorder()
 	}
 
 	return &app, nil
 }
 
 type Application struct {
-	p            Parameters
-	logger       *slog.Logger
-	db           *pgxpool.Pool
-	q            *postgres.Queries
-	checkers     map[string]*hunspell.Checker
-	entryUpdates chan EntryUpdateNotification
-
-	m       sync.RWMutex
-	phrases map[string]*trie.RuneTrie
+	p              Parameters
+	logger         *slog.Logger
+	db             *pgxpool.Pool
+	q              *postgres.Queries
+	metrics        *metrics
+	maxSuggestions int
+	limiter        *subjectLimiter
+	suggestions    *SuggestionCache
+	textLimits     TextLimits
+	entryUpdates   chan EntryUpdateNotification
+	// webhooks is the queue of notifications to post to the webhook, it's
+	// nil if no webhook has been configured.
+	webhooks chan EntryUpdateNotification
+	// gaps records misspelled words without suggestions, it's nil if gap
+	// recording is disabled.
+	gaps *gapRecorder
+
+	// ready is set once the custom entries have been loaded.
+	ready atomic.Bool
+	// listening is set while we're listening for entry update
+	// notifications.
+	listening atomic.Bool
+
+	// reloadM serialises dictionary reloads.
+	reloadM sync.Mutex
+
+	m sync.RWMutex
+	// checkers can be replaced by a dictionary reload, always look them
+	// up under the read lock.
+	checkers map[string]*hunspell.Pool
+	phrases  map[string]*trie.RuneTrie
+	// loaded keeps track of the custom entries that have been loaded into
+	// memory, keyed by language and entry text.
+	loaded map[string]map[string]bool
+	// phraseLength is the number of words in the longest loaded phrase
+	// per language.
+	phraseLength map[string]int
+	// syncedAt is the time up to which the loaded entries of a language
+	// are known to be in sync with the database.
+	syncedAt map[string]time.Time
 }
 
 func (a *Application) Run(ctx context.Context) error {
@@ -167,6 +292,27 @@ func (a *Application) Run(ctx context.Context) error {
 	server.RegisterAPI(checkServer, opts)
 	server.RegisterAPI(dictServer, opts)
 
+	a.registerHTTPHandlers(server.Mux)
+
+	server.Health.AddReadyFunction("dictionaries",
+		func(_ context.Context) error {
+			if !a.ready.Load() {
+				return errors.New("custom entries haven't been loaded yet")
+			}
+
+			return nil
+		})
+
+	// Without the listener the custom entries would silently go stale.
+	server.Health.AddReadyFunction("notification_listener",
+		func(_ context.Context) error {
+			if !a.listening.Load() {
+				return errors.New("not listening for entry updates")
+			}
+
+			return nil
+		})
+
 	grp := elephantine.NewErrGroup(ctx, a.logger)
 
 	grp.Go("server", func(ctx context.Context) error {
@@ -187,20 +333,41 @@ func (a *Application) Run(ctx context.Context) error {
 			return fmt.Errorf("preload entries: %w", err)
 		}
 
+		if a.p.SeedDir != "" {
+			err := a.seedEntries(ctx)
+			if err != nil {
+				return fmt.Errorf("seed entries: %w", err)
+			}
+		}
+
+		a.ready.Store(true)
+
+		return a.runEntryUpdater(ctx)
+	})
+
+	if a.webhooks != nil {
+		grp.Go("webhook_sender", a.runWebhookSender)
+	}
+
+	if a.gaps != nil {
+		grp.Go("gap_recorder", a.runGapRecorder)
+	}
+
+	grp.Go("drift_monitor", func(ctx context.Context) error {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+
 		for {
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
-			case n, ok := <-a.entryUpdates:
-				if !ok {
-					return nil
-				}
+			case <-ticker.C:
+			}
 
-				err := a.handleEntryUpdate(ctx, n)
-				if err != nil {
-					return fmt.Errorf("handle %s update of %q: %w",
-						n.Language, n.Text, err)
-				}
+			err := a.measureEntryDrift(ctx)
+			if err != nil {
+				a.logger.ErrorContext(ctx, "failed to measure entry drift",
+					elephantine.LogKeyError, err)
 			}
 		}
 	})
@@ -208,13 +375,44 @@ func (a *Application) Run(ctx context.Context) error {
 	return grp.Wait()
 }
 
+// measureEntryDrift compares the number of live entries in the database with
+// the number of entries that we have loaded into memory. A sustained
+// difference means that we have missed entry update notifications.
+func (a *Application) measureEntryDrift(ctx context.Context) error {
+	rows, err := a.q.GetDictionaryStats(ctx)
+	if err != nil {
+		return fmt.Errorf("count entries in database: %w", err)
+	}
+
+	stored := make(map[string]int64, len(rows))
+
+	for _, row := range rows {
+		if !isLiveStatus(row.Status) {
+			continue
+		}
+
+		stored[row.Language] += row.Entries
+	}
+
+	a.m.RLock()
+	defer a.m.RUnlock()
+
+	for language := range a.checkers {
+		drift := stored[language] - int64(len(a.loaded[language]))
+
+		a.metrics.entryDrift.WithLabelValues(language).Set(float64(drift))
+	}
+
+	return nil
+}
+
 // SupportedLanguages implements spell.Dictionaries.
 func (a *Application) SupportedLanguages(
 	ctx context.Context, req *spell.SupportedLanguagesRequest,
 ) (*spell.SupportedLanguagesResponse, error) {
 	var res spell.SupportedLanguagesResponse
 
-	for language := range a.checkers {
+	for _, language := range a.languageCodes() {
 		res.Languages = append(res.Languages, &spell.Language{
 			Code: language,
 		})
@@ -223,15 +421,37 @@ func (a *Application) SupportedLanguages(
 	return &res, nil
 }
 
+// languageCodes returns the sorted codes of the loaded languages.
+func (a *Application) languageCodes() []string {
+	a.m.RLock()
+	defer a.m.RUnlock()
+
+	return slices.Sorted(maps.Keys(a.checkers))
+}
+
+// isSupportedLanguage returns true if a dictionary has been loaded for the
+// language.
+func (a *Application) isSupportedLanguage(language string) bool {
+	a.m.RLock()
+	defer a.m.RUnlock()
+
+	_, ok := a.checkers[NormalizeLanguage(language)]
+
+	return ok
+}
+
+// unsupportedLanguageError returns an invalid argument error that lists the
+// supported languages.
+func (a *Application) unsupportedLanguageError(field string, language string) error {
+	return twirp.InvalidArgumentError(field, fmt.Sprintf(
+		"unsupported language %q, supported languages are: %s",
+		language, strings.Join(a.languageCodes(), ", ")))
+}
+
 // DeleteEntry implements spell.Dictionaries.
 func (a *Application) DeleteEntry(
 	ctx context.Context, req *spell.DeleteEntryRequest,
 ) (_ *spell.DeleteEntryResponse, outErr error) {
-	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckWrite)
-	if err != nil {
-		return nil, err //nolint: wrapcheck
-	}
-
 	if req.Language == "" {
 		return nil, twirp.RequiredArgumentError("language")
 	}
@@ -240,6 +460,18 @@ func (a *Application) DeleteEntry(
 		return nil, twirp.RequiredArgumentError("text")
 	}
 
+	if !a.isSupportedLanguage(req.Language) {
+		return nil, a.unsupportedLanguageError("language", req.Language)
+	}
+
+	language := NormalizeLanguage(req.Language)
+	text := NormalizeEntryText(req.Text)
+
+	auth, err := requireWriteAccess(ctx, language)
+	if err != nil {
+		return nil, err
+	}
+
 	tx, err := a.db.Begin(ctx)
 	if err != nil {
 		return nil, twirp.InternalErrorf("start transaction: %w", err)
@@ -247,19 +479,21 @@ func (a *Application) DeleteEntry(
 
 	defer pg.Rollback(tx, &outErr)
 
-	q := a.q.WithTx(tx)
+	q := a.queries(tx)
 
-	err = a.q.DeleteEntry(ctx, postgres.DeleteEntryParams{
-		Language: req.Language,
-		Entry:    req.Text,
+	// Entries are only marked as deleted so that they can be restored.
+	err = deleteEntryWithHistory(ctx, q, postgres.DeleteEntryParams{
+		UpdatedBy: auth.Claims.Subject,
+		Language:  language,
+		Entry:     text,
 	})
 	if err != nil {
 		return nil, twirp.InternalErrorf("write to database: %w", err)
 	}
 
 	err = notifyEntryUpdated(ctx, q, EntryUpdateNotification{
-		Language: req.Language,
-		Text:     req.Text,
+		Language: language,
+		Text:     text,
 		Deleted:  true,
 	})
 	if err != nil {
@@ -292,9 +526,13 @@ func (a *Application) GetEntry(
 	}
 
 	row, err := a.q.GetEntry(ctx, postgres.GetEntryParams{
-		Language: req.Language,
-		Entry:    req.Text,
+		Language: NormalizeLanguage(req.Language),
+		Entry:    NormalizeEntryText(req.Text),
 	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, twirp.NotFoundError("no such entry")
+	}
+
 	if err != nil {
 		return nil, twirp.InternalErrorf("read from database: %w", err)
 	}
@@ -341,6 +579,9 @@ func (a *Application) ListDictionaries(
 }
 
 // ListEntries implements spell.Dictionaries.
+//
+// Deprecated: offset pagination is kept for one more release, clients should
+// use the cursor based GET /entries endpoint instead.
 func (a *Application) ListEntries(
 	ctx context.Context,
 	req *spell.ListEntriesRequest,
@@ -350,21 +591,13 @@ func (a *Application) ListEntries(
 		return nil, err //nolint: wrapcheck
 	}
 
-	if strings.Contains(req.Prefix, "%") {
-		return nil, twirp.InvalidArgumentError("prefix", "prefix cannot contain '%'")
-	}
-
-	var pattern string
-
-	if req.Prefix != "" {
-		pattern = req.Prefix + "%"
-	}
+	pattern := prefixPattern(req.Prefix)
 
 	limit := int64(100)
 	offset := limit * req.Page
 
 	rows, err := a.q.ListEntries(ctx, postgres.ListEntriesParams{
-		Language: pg.TextOrNull(req.Language),
+		Language: pg.TextOrNull(NormalizeLanguage(req.Language)),
 		Pattern:  pg.TextOrNull(pattern),
 		Status:   pg.TextOrNull(req.Status),
 		Limit:    limit,
@@ -395,31 +628,22 @@ func (a *Application) ListEntries(
 func (a *Application) SetEntry(
 	ctx context.Context, req *spell.SetEntryRequest,
 ) (_ *spell.SetEntryResponse, outErr error) {
-	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckWrite)
-	if err != nil {
-		return nil, err //nolint: wrapcheck
-	}
-
 	if req.Entry == nil {
 		return nil, twirp.RequiredArgumentError("entry")
 	}
 
-	if req.Entry.Language == "" {
-		return nil, twirp.RequiredArgumentError("entry.language")
-	}
-
-	_, ok := a.checkers[req.Entry.Language]
-	if !ok {
-		return nil, twirp.InvalidArgumentError("entry.language",
-			fmt.Sprintf("unknown language %q", req.Entry.Language))
-	}
+	// Twirp writes get the same normalization and validation as the
+	// HTTP endpoints.
+	entry := entryRecordFromCustomEntry(req.Entry)
 
-	if req.Entry.Text == "" {
-		return nil, twirp.RequiredArgumentError("entry.text")
+	err := a.prepareEntryRecord("entry", &entry)
+	if err != nil {
+		return nil, err
 	}
 
-	if req.Entry.Status == "" {
-		return nil, twirp.RequiredArgumentError("entry.status")
+	auth, err := requireWriteAccess(ctx, entry.Language)
+	if err != nil {
+		return nil, err
 	}
 
 	tx, err := a.db.Begin(ctx)
@@ -429,22 +653,16 @@ func (a *Application) SetEntry(
 
 	defer pg.Rollback(tx, &outErr)
 
-	q := a.q.WithTx(tx)
+	q := a.queries(tx)
 
-	err = q.SetEntry(ctx, postgres.SetEntryParams{
-		Language:       req.Entry.Language,
-		Entry:          req.Entry.Text,
-		Status:         req.Entry.Status,
-		Description:    req.Entry.Description,
-		CommonMistakes: req.Entry.CommonMistakes,
-	})
+	err = setEntryWithHistory(ctx, q, entry.SetEntryParams(auth.Claims.Subject))
 	if err != nil {
 		return nil, twirp.InternalErrorf("write to database: %w", err)
 	}
 
 	err = notifyEntryUpdated(ctx, q, EntryUpdateNotification{
-		Language: req.Entry.Language,
-		Text:     req.Entry.Text,
+		Language: entry.Language,
+		Text:     entry.Text,
 	})
 	if err != nil {
 		return nil, twirp.InternalErrorf("send notification: %w", err)
@@ -458,52 +676,618 @@ func (a *Application) SetEntry(
 	return &spell.SetEntryResponse{}, nil
 }
 
+// validateEntry validates a custom entry, field is used as the prefix for the
+// argument names in the returned errors.
+func (a *Application) validateEntry(field string, entry *spell.CustomEntry) error {
+	if entry == nil {
+		return twirp.RequiredArgumentError(field)
+	}
+
+	if entry.Language == "" {
+		return twirp.RequiredArgumentError(field + ".language")
+	}
+
+	if !a.isSupportedLanguage(entry.Language) {
+		return a.unsupportedLanguageError(
+			field+".language", entry.Language)
+	}
+
+	if entry.Text == "" {
+		return twirp.RequiredArgumentError(field + ".text")
+	}
+
+	if entry.Status == "" {
+		return twirp.RequiredArgumentError(field + ".status")
+	}
+
+	return nil
+}
+
 // Text implements spell.Check.
 func (a *Application) Text(
 	ctx context.Context, req *spell.TextRequest,
 ) (*spell.TextResponse, error) {
-	_, ok := elephantine.GetAuthInfo(ctx)
+	auth, ok := elephantine.GetAuthInfo(ctx)
 	if !ok {
 		return nil, twirp.Unauthenticated.Error("unauthenticated")
 	}
 
-	langCode := strings.ToLower(req.Language)
+	err := a.limiter.Allow(auth.Claims.Subject, req.Text...)
+	if err != nil {
+		return nil, err
+	}
+
+	// TextRequest has no field for a suggestion limit, so the server
+	// limit applies.
+	res, _, langCode, err := a.checkTexts(req.Language, req.Text, checkOptions{
+		MaxSuggestions: a.maxSuggestions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if isAutoLanguage(req.Language) {
+		// TextResponse has no field for the language, so we report
+		// it in a header instead.
+		err = twirp.SetHTTPResponseHeader(ctx,
+			"X-Spell-Language", langCode)
+		if err != nil {
+			return nil, twirp.InternalErrorf(
+				"set language header: %w", err)
+		}
+	}
+
+	return res, nil
+}
+
+// checkTexts spellchecks the texts, detecting the language if it's "auto".
+// Returns the details of the check of each text, and the language that was
+// used for the check.
+func (a *Application) checkTexts(
+	language string, texts []string, opts checkOptions,
+) (*spell.TextResponse, []checkDetails, string, error) {
+	err := a.textLimits.Validate(texts)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if isAutoLanguage(language) {
+		scores, err := a.detectLanguage(texts)
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		language = scores[0].Language
+	}
+
+	checker, langCode, release, err := a.checkerForLanguage(language)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	defer release()
+
+	checker, err = glossaryChecker(checker, opts.Glossary)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	a.metrics.textRequests.WithLabelValues(langCode).Inc()
+
+	res := spell.TextResponse{
+		Misspelled: make([]*spell.Misspelled, len(texts)),
+	}
+
+	details := make([]checkDetails, len(texts))
+
+	for i := range texts {
+		res.Misspelled[i] = a.spellcheck(
+			texts[i], checker, langCode, opts, nil, &details[i])
+	}
+
+	return &res, details, langCode, nil
+}
+
+// checkOptions controls which words are checked.
+type checkOptions struct {
+	Ignore ignoreList
+	// CheckNumbers makes words that contain digits, f.ex. "3D", be
+	// checked.
+	CheckNumbers bool
+	// SkipSuggestions leaves out the hunspell suggestions for misspelled
+	// words, for callers that only want to know if a text is clean.
+	SkipSuggestions bool
+	// Glossary is the name of a glossary to check against in addition to
+	// the dictionary.
+	Glossary string
+	// MaxSuggestions is the maximum number of suggestions per misspelled
+	// word that the caller wants, zero is unlimited. The server limit
+	// applies if it's smaller.
+	MaxSuggestions int
+}
+
+// suggestionLimit returns the maximum number of suggestions per misspelled
+// word, the smaller of the server limit and the limit of the options. Zero is
+// unlimited.
+func (a *Application) suggestionLimit(opts checkOptions) int {
+	switch {
+	case opts.MaxSuggestions <= 0:
+		return a.maxSuggestions
+	case a.maxSuggestions <= 0:
+		return opts.MaxSuggestions
+	}
+
+	return min(a.maxSuggestions, opts.MaxSuggestions)
+}
+
+// skip returns the verdict for a word that shouldn't be checked.
+func (o checkOptions) skip(word string) (Verdict, bool) {
+	switch {
+	case o.Ignore.Contains(word):
+		return VerdictIgnored, true
+	case !o.CheckNumbers && HasDigit(word):
+		return VerdictNumber, true
+	}
+
+	return "", false
+}
+
+// ignoreList is a set of lower cased words that shouldn't be flagged as
+// misspelled.
+type ignoreList map[string]bool
+
+func newIgnoreList(words []string) ignoreList {
+	if len(words) == 0 {
+		return nil
+	}
+
+	l := make(ignoreList, len(words))
+
+	for _, w := range words {
+		l[strings.ToLower(w)] = true
+	}
+
+	return l
+}
+
+// Contains returns true if the word is in the list, ignoring case.
+func (l ignoreList) Contains(word string) bool {
+	if len(l) == 0 {
+		return false
+	}
+
+	return l[strings.ToLower(word)]
+}
+
+// segmentation returns the segmentation configuration for a language.
+func (a *Application) segmentation(language string) Segmentation {
+	s := a.p.Segmentation[language]
+
+	if a.p.SentenceBoundedPhrases {
+		s.SentenceBounded = true
+	}
+
+	return s
+}
+
+// checkerForLanguage returns the checkers for a language together with the
+// normalized language code. The checkers are held until the returned release
+// function is called.
+func (a *Application) checkerForLanguage(
+	language string,
+) (*hunspell.Pool, string, func(), error) {
+	langCode := NormalizeLanguage(language)
+
+	// The checker is held before the lock is released, so that a
+	// dictionary reload can't retire it under us.
+	a.m.RLock()
 
 	checker, ok := a.checkers[langCode]
+
+	var (
+		release func()
+		held    bool
+	)
+
+	if ok {
+		release, held = checker.Hold()
+	}
+
+	a.m.RUnlock()
+
+	switch {
+	case !ok:
+		return nil, "", nil, a.unsupportedLanguageError("language", language)
+	case !held:
+		return nil, "", nil, twirp.Unavailable.Error(
+			"the dictionary is being replaced")
+	}
+
+	return checker, langCode, release, nil
+}
+
+// glossaryChecker returns the checkers for a glossary of the language, or the
+// checkers of the language itself if no glossary was asked for.
+func glossaryChecker(
+	checker *hunspell.Pool, glossary string,
+) (*hunspell.Pool, error) {
+	if glossary == "" {
+		return checker, nil
+	}
+
+	g, ok := checker.Glossary(strings.ToLower(glossary))
 	if !ok {
-		return nil, twirp.InvalidArgument.Errorf("unsupported language %q", req.Language)
+		return nil, twirp.InvalidArgumentError("glossary", fmt.Sprintf(
+			"unknown glossary, the language has: %s",
+			strings.Join(checker.Glossaries(), ", ")))
 	}
 
-	res := spell.TextResponse{
-		Misspelled: make([]*spell.Misspelled, len(req.Text)),
+	return g, nil
+}
+
+// suggest returns suggestions for a single word or phrase, common mistake
+// corrections before hunspell suggestions. Returns false if the text is
+// spelled correctly.
+func (a *Application) suggest(
+	text string, pool *hunspell.Pool, langCode string,
+) ([]*spell.Suggestion, bool) {
+	text = NormalizeEntryText(text)
+
+	a.m.RLock()
+	p, folded := lookupPhrase(a.phrases[langCode], text)
+	a.m.RUnlock()
+
+	var suggestions []*spell.Suggestion
+
+	switch {
+	case p != nil && p.Text == text:
+		return nil, false
+	case p != nil:
+		suggestion := p.Text
+
+		if folded {
+			suggestion = MatchCapitalization(text, suggestion)
+		}
+
+		suggestions = append(suggestions, &spell.Suggestion{
+			Text:        suggestion,
+			Description: p.Description,
+		})
+	case pool.Spell(text):
+		return nil, false
+	}
+
+	suggested := a.limitHunspellSuggestions(
+		SuggestWithStemFallback(pool, text))
+
+	for _, sugg := range suggested {
+		sugg = MatchCapitalization(text, sugg)
+
+		if hasSuggestion(suggestions, sugg) {
+			continue
+		}
+
+		suggestions = append(suggestions, &spell.Suggestion{
+			Text: sugg,
+		})
 	}
 
-	for i := range req.Text {
-		res.Misspelled[i] = a.spellcheck(req.Text[i], checker, langCode)
+	if a.maxSuggestions > 0 && len(suggestions) > a.maxSuggestions {
+		suggestions = suggestions[:a.maxSuggestions]
 	}
 
-	return &res, nil
+	return suggestions, true
+}
+
+// EntrySource tells where the suggestions for a misspelled entry came from.
+type EntrySource string
+
+const (
+	// SourceCommonMistake is used for entries that matched a common
+	// mistake of a custom entry.
+	SourceCommonMistake EntrySource = "common_mistake"
+	// SourceHunspell is used for words that hunspell rejected.
+	SourceHunspell EntrySource = "hunspell"
+)
+
+// Level tells how sure we are that a flagged entry is wrong.
+type Level string
+
+const (
+	// LevelAuto is only used for entries, and flags the common mistakes
+	// of the entry at suggestion level if they are correctly spelled
+	// words, f.ex. "dem" where "de" is the right choice, as they can be
+	// right in another context. Other mistakes are flagged as errors.
+	LevelAuto Level = "auto"
+	// LevelError is used for misspelled words and common mistakes that
+	// are wrong in any context.
+	LevelError Level = "error"
+	// LevelSuggestion is used for common mistakes that are only wrong in
+	// some contexts.
+	LevelSuggestion Level = "suggestion"
+)
+
+// validateLevel checks that the level of an entry is known, an empty level
+// keeps the level of an existing entry.
+func validateLevel(field string, level string) error {
+	switch Level(level) {
+	case "", LevelAuto, LevelError, LevelSuggestion:
+		return nil
+	}
+
+	return twirp.InvalidArgumentError(field, fmt.Sprintf(
+		"must be %q, %q, or %q", LevelAuto, LevelError, LevelSuggestion))
+}
+
+// mistakeLevel returns the level that a matched common mistake should be
+// flagged at, resolving the automatic level of the entry with the checker.
+// The mistakes of enforced entries are always errors.
+func mistakeLevel(
+	checker *hunspell.Checker, segmentation Segmentation,
+	p *phrase, text string,
+) Level {
+	if p.Enforced {
+		return LevelError
+	}
+
+	if p.Level != LevelAuto {
+		return p.Level
+	}
+
+	words := slices.Collect(segmentation.Words([]byte(text)))
+
+	if len(words) > 0 && !slices.Contains(checker.SpellMany(words), false) {
+		return LevelSuggestion
+	}
+
+	return LevelError
+}
+
+// entrySource returns the source of a misspelled entry. Common mistakes take
+// precedence, as that's where the first suggestion comes from.
+func (a *Application) entrySource(langCode string, text string) EntrySource {
+	key := NormalizeEntryText(text)
+
+	a.m.RLock()
+	p, _ := lookupPhrase(a.phrases[langCode], key)
+	a.m.RUnlock()
+
+	if p != nil && p.Text != key {
+		return SourceCommonMistake
+	}
+
+	return SourceHunspell
+}
+
+// lookupPhrase finds the custom entry or common mistake that matches the
+// text. Common mistakes are also matched on the case folded text, in which
+// case folded is true.
+func lookupPhrase(phrases *trie.RuneTrie, text string) (_ *phrase, folded bool) {
+	p, ok := phrases.Get(text).(*phrase)
+	if ok {
+		return p, false
+	}
+
+	p, ok = phrases.Get(strings.ToLower(text)).(*phrase)
+	if !ok || strings.EqualFold(p.Text, text) {
+		// Different capitalisation of an entry is left to hunspell.
+		return nil, false
+	}
+
+	return p, true
+}
+
+// Verdict describes the outcome of checking a word or phrase.
+type Verdict string
+
+const (
+	// VerdictMisspelled is used for words that hunspell rejected.
+	VerdictMisspelled Verdict = "misspelled"
+	// VerdictCommonMistake is used for phrases that matched a common
+	// mistake of a custom entry.
+	VerdictCommonMistake Verdict = "common_mistake"
+	// VerdictContextMistake is used for phrases that matched a common
+	// mistake of a custom entry at suggestion level.
+	VerdictContextMistake Verdict = "context_mistake"
+	// VerdictCustomEntry is used for phrases that matched a custom entry.
+	VerdictCustomEntry Verdict = "custom_entry"
+	// VerdictHunspell is used for words that hunspell accepted.
+	VerdictHunspell Verdict = "hunspell"
+	// VerdictIgnored is used for words that were in the ignore list of
+	// the request.
+	VerdictIgnored Verdict = "ignored"
+	// VerdictNumber is used for words that contain digits and weren't
+	// checked.
+	VerdictNumber Verdict = "number"
+)
+
+// TokenVerdict is the verdict for a single word or phrase.
+type TokenVerdict struct {
+	Text    string  `json:"text"`
+	Verdict Verdict `json:"verdict"`
+}
+
+// checkTrace collects the verdicts for all words and phrases that were
+// considered during a spellcheck. A nil trace discards all verdicts.
+type checkTrace struct {
+	Tokens []TokenVerdict `json:"tokens"`
+}
+
+func (t *checkTrace) record(text string, v Verdict) {
+	if t == nil {
+		return
+	}
+
+	t.Tokens = append(t.Tokens, TokenVerdict{
+		Text:    text,
+		Verdict: v,
+	})
+}
+
+// AcceptedEntry is a custom entry that made a word or phrase in a text be
+// accepted.
+type AcceptedEntry struct {
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedBy string    `json:"updated_by"`
+}
+
+// checkDetails collects the details of a spellcheck that spell.Misspelled
+// has no fields for. A nil value discards the details.
+type checkDetails struct {
+	// Accepted are the custom entries that were matched, every entry is
+	// only added once.
+	Accepted []AcceptedEntry
+	// SuggestionLevel are the matched common mistakes that are flagged at
+	// suggestion level.
+	SuggestionLevel map[string]bool
+	// Enforced are the matched common mistakes of enforced entries.
+	Enforced map[string]bool
+	// Ranges are the ranges of the flagged entries in the original
+	// text, keyed by entry text.
+	Ranges map[string][]TextRange
+}
+
+func (d *checkDetails) accept(p *phrase) {
+	if d == nil {
+		return
+	}
+
+	seen := slices.ContainsFunc(d.Accepted, func(e AcceptedEntry) bool {
+		return e.Text == p.Text
+	})
+	if seen {
+		return
+	}
+
+	d.Accepted = append(d.Accepted, AcceptedEntry{
+		Text:      p.Text,
+		UpdatedAt: p.UpdatedAt,
+		UpdatedBy: p.UpdatedBy,
+	})
+}
+
+// locate finds the ranges of the flagged entries in the text. The ranges
+// refer to the text as it was before normalization.
+func (d *checkDetails) locate(
+	segmentation Segmentation, text NormalizedText,
+	entries []*spell.MisspelledEntry,
+) {
+	if d == nil || len(entries) == 0 {
+		return
+	}
+
+	var phrases []string
+
+	for _, e := range entries {
+		// Whole entries were located when they were matched.
+		if _, ok := d.Ranges[e.Text]; ok {
+			continue
+		}
+
+		phrases = append(phrases, e.Text)
+	}
+
+	if d.Ranges == nil {
+		d.Ranges = make(map[string][]TextRange, len(entries))
+	}
+
+	maps.Copy(d.Ranges, segmentation.Locate([]byte(text.Text), phrases))
+
+	for _, found := range d.Ranges {
+		for i := range found {
+			found[i] = text.Original(found[i])
+		}
+	}
+}
+
+func (d *checkDetails) suggestionLevel(text string) {
+	if d == nil {
+		return
+	}
+
+	if d.SuggestionLevel == nil {
+		d.SuggestionLevel = make(map[string]bool)
+	}
+
+	d.SuggestionLevel[text] = true
+}
+
+// located records the ranges of an entry in the normalized text, for entries
+// that only match at some of their occurrences.
+func (d *checkDetails) located(text string, ranges []TextRange) {
+	if d == nil {
+		return
+	}
+
+	if d.Ranges == nil {
+		d.Ranges = make(map[string][]TextRange)
+	}
+
+	d.Ranges[text] = ranges
+}
+
+func (d *checkDetails) enforced(text string) {
+	if d == nil {
+		return
+	}
+
+	if d.Enforced == nil {
+		d.Enforced = make(map[string]bool)
+	}
+
+	d.Enforced[text] = true
 }
 
 func (a *Application) spellcheck(
-	text string, checker *hunspell.Checker, langCode string,
+	text string, pool *hunspell.Pool, langCode string,
+	opts checkOptions, trace *checkTrace, details *checkDetails,
 ) *spell.Misspelled {
 	var res spell.Misspelled
 
+	normalized := NormalizeWithOffsets(text)
+	text = normalized.Text
+
+	checker, release := pool.Acquire()
+	defer release()
+
 	textData := []byte(text)
+	original := textData
+
+	segmentation := a.segmentation(langCode)
 
 	a.m.RLock()
 	trie := a.phrases[langCode]
+	phraseLength := max(a.phraseLength[langCode], 1)
 
-	for text := range PhraseIterator(textData, 3) {
-		v := trie.Get(text)
+	for text := range segmentation.Phrases(textData, phraseLength) {
+		// The phrase keeps the whitespace of the text, the entries are
+		// stored with normalized whitespace.
+		key := NormalizeEntryText(text)
 
-		p, ok := v.(*phrase)
-		if !ok {
+		p, folded := lookupPhrase(trie, key)
+		if p == nil {
 			continue
 		}
 
-		if p.Text != text {
+		// Whole entries only match where they stand alone, the other
+		// occurrences are left to hunspell.
+		var standalone []TextRange
+
+		if p.Whole {
+			standalone = segmentation.Standalone(original, text)
+			if len(standalone) == 0 {
+				continue
+			}
+		}
+
+		if p.Text != key {
+			suggestion := p.Text
+
+			if folded {
+				suggestion = MatchCapitalization(text, suggestion)
+			}
+
 			// Make sure that we only act once on a custom entry.
 			oldNews := slices.ContainsFunc(res.Entries,
 				func(m *spell.MisspelledEntry) bool {
@@ -513,69 +1297,318 @@ func (a *Application) spellcheck(
 				continue
 			}
 
+			if mistakeLevel(checker, segmentation, p, text) == LevelSuggestion {
+				trace.record(text, VerdictContextMistake)
+				details.suggestionLevel(text)
+			} else {
+				trace.record(text, VerdictCommonMistake)
+			}
+
+			if p.Enforced {
+				details.enforced(text)
+			}
+
+			if p.Whole {
+				details.located(text, standalone)
+			}
+
 			res.Entries = append(res.Entries,
 				&spell.MisspelledEntry{
 					Text: text,
 					Suggestions: []*spell.Suggestion{
 						{
-							Text:        p.Text,
+							Text:        suggestion,
 							Description: p.Description,
 						},
 					},
 				})
+		} else {
+			trace.record(text, VerdictCustomEntry)
+			details.accept(p)
 		}
 
-		textData = bytes.ReplaceAll(textData, []byte(text), nil)
+		// Blank out the phrase rather than removing it, so that the
+		// standalone occurrences of later phrases still line up with
+		// the text.
+		if p.Whole {
+			textData = blankRanges(textData, standalone)
+		} else {
+			textData = bytes.ReplaceAll(textData, []byte(text),
+				bytes.Repeat([]byte{' '}, len(text)))
+		}
 	}
 
 	a.m.RUnlock()
 
-	seg := segment.NewSegmenter(bytes.NewReader(textData))
-
-	seen := make(map[string]bool)
+	var (
+		words []string
+		check []string
+		seen  = make(map[string]bool)
+	)
 
-	for seg.Segment() {
-		if seg.Type() != segment.Letter {
+	for word := range segmentation.Words(textData) {
+		if seen[word] {
 			continue
 		}
 
-		word := seg.Text()
+		seen[word] = true
+
+		words = append(words, word)
+
+		_, skip := opts.skip(word)
+		if !skip {
+			check = append(check, word)
+		}
+	}
+
+	a.metrics.wordsChecked.WithLabelValues(langCode).Add(float64(len(check)))
+
+	// Check all the words in one call instead of crossing over to hunspell
+	// once per word.
+	spelledCorrectly := checker.SpellMany(check)
+
+	var checked int
+
+	for _, word := range words {
+		verdict, skip := opts.skip(word)
+		if skip {
+			trace.record(word, verdict)
 
-		if seen[word] {
 			continue
 		}
 
-		seen[word] = true
+		correct := spelledCorrectly[checked]
+		checked++
 
-		correct := checker.Spell(word)
 		if correct {
+			trace.record(word, VerdictHunspell)
+
 			continue
 		}
 
-		var suggestions []*spell.Suggestion
+		// Fall back to checking the parts of hyphenated words that
+		// aren't known as a whole.
+		misspelled := segmentation.MisspelledParts(checker, word)
+		if len(misspelled) == 0 {
+			trace.record(word, VerdictHunspell)
 
-		for _, sugg := range checker.Suggest(word) {
-			suggestions = append(suggestions, &spell.Suggestion{
-				Text: sugg,
-			})
+			continue
 		}
 
-		res.Entries = append(res.Entries, &spell.MisspelledEntry{
-			Text:        word,
-			Suggestions: suggestions,
-		})
+		trace.record(word, VerdictMisspelled)
+
+		for _, part := range misspelled {
+			entry := spell.MisspelledEntry{
+				Text: part,
+			}
+
+			if !opts.SkipSuggestions {
+				entry.Suggestions = a.wordSuggestions(
+					checker, langCode, opts.Glossary, part)
+
+				if len(entry.Suggestions) == 0 {
+					a.gaps.Record(langCode, part)
+				}
+			}
+
+			res.Entries = append(res.Entries, &entry)
+		}
 	}
 
+	res.Entries = MergeDuplicateEntries(res.Entries)
+
+	details.locate(segmentation, normalized, res.Entries)
+
+	LimitSuggestions(res.Entries, a.suggestionLimit(opts))
+
+	a.metrics.wordsFlagged.WithLabelValues(langCode).Add(
+		float64(len(res.Entries)))
+
 	return &res
 }
 
+// wordSuggestions returns the hunspell suggestions for a misspelled word,
+// with the capitalisation of the word.
+func (a *Application) wordSuggestions(
+	checker *hunspell.Checker, langCode string, glossary string, word string,
+) []*spell.Suggestion {
+	var suggestions []*spell.Suggestion
+
+	suggested := a.cachedSuggestions(checker, langCode, glossary, word)
+
+	for _, sugg := range suggested {
+		sugg = MatchCapitalization(word, sugg)
+
+		if hasSuggestion(suggestions, sugg) {
+			continue
+		}
+
+		suggestions = append(suggestions, &spell.Suggestion{
+			Text: sugg,
+		})
+	}
+
+	return suggestions
+}
+
+// cachedSuggestions returns the suggestions for a misspelled word from the
+// suggestion cache, asking hunspell on a miss.
+func (a *Application) cachedSuggestions(
+	checker *hunspell.Checker, langCode string, glossary string, word string,
+) []string {
+	cached, generation, ok := a.suggestions.Get(langCode, glossary, word)
+	if ok {
+		a.metrics.suggestionCache.WithLabelValues(langCode, "hit").Inc()
+
+		return cached
+	}
+
+	if a.suggestions != nil {
+		a.metrics.suggestionCache.WithLabelValues(langCode, "miss").Inc()
+	}
+
+	suggestStart := time.Now()
+	suggested := a.limitHunspellSuggestions(
+		SuggestWithStemFallback(checker, word))
+
+	a.metrics.suggestDuration.WithLabelValues(langCode).Observe(
+		time.Since(suggestStart).Seconds())
+
+	a.suggestions.Add(langCode, glossary, word, generation, suggested)
+
+	return suggested
+}
+
+// limitHunspellSuggestions truncates the hunspell suggestions for a word to
+// the configured maximum.
+func (a *Application) limitHunspellSuggestions(suggested []string) []string {
+	limit := a.p.MaxHunspellSuggest
+	if limit > 0 && len(suggested) > limit {
+		return suggested[:limit]
+	}
+
+	return suggested
+}
+
 type EntryUpdateNotification struct {
 	Language string
 	Text     string
 	Deleted  bool
+	// Texts is used to batch updates of several entries in one
+	// notification.
+	Texts []string `json:",omitempty"`
 }
 
-func (a *Application) runListener(ctx context.Context) (outErr error) {
+// AllTexts returns the texts of all entries that the notification concerns.
+func (n EntryUpdateNotification) AllTexts() []string {
+	if n.Text == "" {
+		return n.Texts
+	}
+
+	return append([]string{n.Text}, n.Texts...)
+}
+
+const (
+	listenerMinBackoff = time.Second
+	listenerMaxBackoff = 30 * time.Second
+	// listenerResyncMargin is how long before the listener connection was
+	// lost that we look for changed entries after reconnecting. It covers
+	// transactions that started before, but committed after, the
+	// connection was lost, as updated_at is the start of the transaction.
+	listenerResyncMargin = time.Minute
+)
+
+// runListener listens for entry update notifications until the context is
+// cancelled. A lost connection is re-established with a backoff, and the
+// entries that changed while we weren't listening are queued as updates.
+func (a *Application) runListener(ctx context.Context) error {
+	var lostAt time.Time
+
+	backoff := listenerMinBackoff
+
+	for {
+		err := a.listen(ctx, lostAt)
+
+		listened := a.listening.Swap(false)
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Keep the original time if we never got as far as listening,
+		// so that the resync covers the whole outage.
+		if listened || lostAt.IsZero() {
+			lostAt = time.Now()
+		}
+
+		if listened {
+			backoff = listenerMinBackoff
+		}
+
+		a.metrics.listenerErrors.Inc()
+
+		a.logger.ErrorContext(ctx, "entry update listener failed",
+			elephantine.LogKeyError, err,
+			"retry_in", backoff.String())
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, listenerMaxBackoff)
+	}
+}
+
+// queueChangedEntries queues updates for all entries that have changed since
+// the given time.
+//
+// This replaces a full preloadEntries after a reconnect. preloadEntries only
+// adds entries, so it can't unload entries that were deleted while we weren't
+// listening. The updated_at cursor can't miss deletes: entries are never
+// removed from the table, a delete sets deleted_at and bumps updated_at in
+// the same statement, so a deleted entry is listed with deleted set. The
+// margin covers transactions that committed after the connection was lost
+// with an updated_at from before it, and the periodic reconciliation catches
+// anything that still slips through.
+func (a *Application) queueChangedEntries(
+	ctx context.Context, since time.Time,
+) error {
+	rows, err := a.q.ListChangedEntries(ctx, postgres.ListChangedEntriesParams{
+		Since: pgtype.Timestamptz{
+			Time:  since.Add(-listenerResyncMargin),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("list changed entries: %w", err)
+	}
+
+	for _, row := range rows {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case a.entryUpdates <- EntryUpdateNotification{
+			Language: row.Language,
+			Text:     row.Entry,
+			Deleted:  row.Deleted,
+		}:
+		}
+	}
+
+	a.logger.InfoContext(ctx, "queued entries changed while not listening",
+		"entries", len(rows))
+
+	return nil
+}
+
+// listen listens for notifications on a dedicated connection until the
+// connection fails or the context is cancelled. If resyncSince is set the
+// entries that have changed since then are queued once we're listening.
+func (a *Application) listen(
+	ctx context.Context, resyncSince time.Time,
+) (outErr error) {
 	conn, err := a.db.Acquire(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to acquire connection from pool: %w", err)
@@ -605,6 +1638,17 @@ func (a *Application) runListener(ctx context.Context) (outErr error) {
 		}
 	}
 
+	// Changes that were made while we weren't listening are queued after
+	// LISTEN so that nothing falls between the cracks.
+	if !resyncSince.IsZero() {
+		err := a.queueChangedEntries(ctx, resyncSince)
+		if err != nil {
+			return err
+		}
+	}
+
+	a.listening.Store(true)
+
 	received := make(chan *pgconn.Notification)
 	grp, gCtx := errgroup.WithContext(ctx)
 
@@ -616,7 +1660,11 @@ func (a *Application) runListener(ctx context.Context) (outErr error) {
 					"error while waiting for notification: %w", err)
 			}
 
-			received <- notification
+			select {
+			case <-gCtx.Done():
+				return gCtx.Err()
+			case received <- notification:
+			}
 		}
 	})
 
@@ -624,9 +1672,11 @@ func (a *Application) runListener(ctx context.Context) (outErr error) {
 		for {
 			var notification *pgconn.Notification
 
+			// Stop when the connection fails so that we can
+			// reconnect.
 			select {
-			case <-ctx.Done():
-				return ctx.Err()
+			case <-gCtx.Done():
+				return gCtx.Err()
 			case notification = <-received:
 			}
 
@@ -660,6 +1710,45 @@ func notifyEntryUpdated(
 	return pgNotify(ctx, q, NotifyEntryUpdate, payload)
 }
 
+// maxBatchNotificationSize keeps batched notification payloads well below
+// the 8000 byte limit of pg_notify.
+const maxBatchNotificationSize = 6000
+
+// notifyEntriesUpdated sends batched update notifications for the entries of a
+// language, deleted is set if the entries have been deleted.
+func notifyEntriesUpdated(
+	ctx context.Context, q *postgres.Queries,
+	language string, texts []string, deleted bool,
+) error {
+	var (
+		batch []string
+		size  int
+	)
+
+	for i, text := range texts {
+		batch = append(batch, text)
+		size += len(text)
+
+		if size < maxBatchNotificationSize && i < len(texts)-1 {
+			continue
+		}
+
+		err := notifyEntryUpdated(ctx, q, EntryUpdateNotification{
+			Language: language,
+			Texts:    batch,
+			Deleted:  deleted,
+		})
+		if err != nil {
+			return err
+		}
+
+		batch = nil
+		size = 0
+	}
+
+	return nil
+}
+
 func pgNotify[T any](
 	ctx context.Context, q *postgres.Queries,
 	channel NotifyChannel, payload T,
@@ -2,32 +2,89 @@ package internal
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/dghubble/trie"
+	"github.com/ttab/elephant-spell/hunspell"
 	"github.com/ttab/elephant-spell/postgres"
+	"github.com/ttab/elephantine"
+	"github.com/ttab/elephantine/pg"
+	"golang.org/x/sync/errgroup"
 )
 
 type phrase struct {
 	Text        string
 	Description string
+	// Whole is true if the entry only should match when it stands alone.
+	Whole bool
+	// Level is the level that the common mistakes are flagged at.
+	Level Level
+	// Enforced is true if the common mistakes always are flagged as
+	// errors, regardless of the level.
+	Enforced bool
+	// Forms are inflected forms of the entry that are accepted by the
+	// checkers.
+	Forms []string
+	// AffixModel is a dictionary word that the entry is inflected like.
+	AffixModel string
+	// CommonMistakes are the mistakes that the phrase was stored under,
+	// so that they can be removed with the entry.
+	CommonMistakes []string
+	// UpdatedAt and UpdatedBy tell when and by whom the entry was last
+	// changed.
+	UpdatedAt time.Time
+	UpdatedBy string
 }
 
+// preloadEntries loads the custom entries of all languages into memory, the
+// languages are read from the database concurrently.
 func (a *Application) preloadEntries(ctx context.Context) error {
+	start := time.Now()
+
 	a.m.Lock()
-	defer a.m.Unlock()
+	languages := slices.Collect(maps.Keys(a.checkers))
 
-	var (
-		limit  int64 = 200
-		offset int64
-	)
+	for _, language := range languages {
+		a.syncedAt[language] = start
+	}
 
-	for {
-		rows, err := a.q.ListEntries(ctx, postgres.ListEntriesParams{
-			Limit:  limit,
-			Offset: offset,
+	a.m.Unlock()
+
+	grp, gCtx := errgroup.WithContext(ctx)
+
+	for _, language := range languages {
+		grp.Go(func() error {
+			err := a.preloadLanguage(gCtx, language)
+			if err != nil {
+				return fmt.Errorf("load %s entries: %w", language, err)
+			}
+
+			return nil
 		})
+	}
+
+	err := grp.Wait()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	return nil
+}
+
+// preloadLanguage loads the custom entries of a language page by page, only
+// holding the write lock while a page is applied.
+func (a *Application) preloadLanguage(ctx context.Context, language string) error {
+	params := postgres.IterateEntriesParams{
+		Language: pg.Text(language),
+		Limit:    200,
+	}
+
+	for {
+		rows, err := a.q.IterateEntries(ctx, params)
 		if err != nil {
 			return fmt.Errorf("list entries: %w", err)
 		}
@@ -36,81 +93,421 @@ func (a *Application) preloadEntries(ctx context.Context) error {
 			return nil
 		}
 
-		for _, row := range rows {
-			checker, ok := a.checkers[row.Language]
-			if !ok {
-				continue
+		a.m.Lock()
+
+		checker, hasChecker := a.checkers[language]
+		phrases, hasPhrases := a.phrases[language]
+
+		if hasChecker && hasPhrases {
+			for _, row := range rows {
+				a.loadEntry(checker, phrases, row)
 			}
+		}
 
-			trie, ok := a.phrases[row.Language]
-			if !ok {
-				continue
+		a.m.Unlock()
+
+		a.suggestions.PurgeLanguage(language)
+
+		if !hasChecker || !hasPhrases {
+			// The language was removed by a dictionary reload.
+			return nil
+		}
+
+		last := rows[len(rows)-1]
+
+		params.AfterLanguage = last.Language
+		params.AfterEntry = last.Entry
+	}
+}
+
+// entryUpdateBatch collects entry updates per language and entry text. Only
+// the last update of an entry is kept, the value is true for deletes.
+type entryUpdateBatch map[string]map[string]bool
+
+func (b entryUpdateBatch) Add(n EntryUpdateNotification) {
+	texts, ok := b[n.Language]
+	if !ok {
+		texts = make(map[string]bool)
+		b[n.Language] = texts
+	}
+
+	for _, text := range n.AllTexts() {
+		texts[text] = n.Deleted
+	}
+}
+
+// entryUpdateWindow is how long we collect entry updates before applying
+// them, so that f.ex. an import doesn't cause a write lock per entry.
+const entryUpdateWindow = 100 * time.Millisecond
+
+// runEntryUpdater applies entry updates in batches until the context is
+// cancelled or the update channel is closed.
+func (a *Application) runEntryUpdater(ctx context.Context) error {
+	reconcile := time.NewTicker(entryReconcileInterval)
+	defer reconcile.Stop()
+
+	for {
+		batch := make(entryUpdateBatch)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-reconcile.C:
+			err := a.reconcileEntries(ctx)
+			if err != nil {
+				a.logger.ErrorContext(ctx, "failed to reconcile entries",
+					elephantine.LogKeyError, err)
 			}
 
-			p := phrase{
-				Text:        row.Entry,
-				Description: row.Description,
+			continue
+		case n, ok := <-a.entryUpdates:
+			if !ok {
+				return nil
 			}
 
-			trie.Put(row.Entry, &p)
-			checker.Add(row.Entry)
+			batch.Add(n)
+		}
 
-			for _, cm := range row.CommonMistakes {
-				trie.Put(cm, &p)
+		closed, err := a.collectEntryUpdates(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		for language, updates := range batch {
+			err := a.applyEntryUpdates(ctx, language, updates)
+			if err != nil {
+				return fmt.Errorf("handle %s updates: %w",
+					language, err)
+			}
+
+			for text, deleted := range updates {
+				a.queueWebhook(EntryUpdateNotification{
+					Language: language,
+					Text:     text,
+					Deleted:  deleted,
+				})
 			}
 		}
 
-		offset += limit
+		if closed {
+			return nil
+		}
+	}
+}
+
+// collectEntryUpdates adds incoming updates to the batch until the collection
+// window has passed. Returns true if the update channel was closed.
+func (a *Application) collectEntryUpdates(
+	ctx context.Context, batch entryUpdateBatch,
+) (bool, error) {
+	timer := time.NewTimer(entryUpdateWindow)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-timer.C:
+			return false, nil
+		case n, ok := <-a.entryUpdates:
+			if !ok {
+				return true, nil
+			}
+
+			batch.Add(n)
+		}
 	}
 }
 
-func (a *Application) handleEntryUpdate(
-	ctx context.Context, n EntryUpdateNotification,
+// entryStagingThreshold is the number of updates in a batch from which the
+// changes are staged in a copy of the phrase trie. Copying the trie costs
+// time in proportion to the number of entries, so smaller batches are
+// applied in place under the write lock instead.
+const entryStagingThreshold = 50
+
+// applyEntryUpdates reads the updated entries for a language from the
+// database and applies all the changes in one pass. Large batches are staged
+// in a copy of the phrase trie outside of the write lock, which is then only
+// held to swap in the new trie and to update the checkers. Must only be
+// called from the entry updater goroutine.
+func (a *Application) applyEntryUpdates(
+	ctx context.Context, language string, updates map[string]bool,
 ) error {
+	params := postgres.GetEntriesParams{
+		Languages: make([]string, 0, len(updates)),
+		Entries:   make([]string, 0, len(updates)),
+	}
+
+	for text, deleted := range updates {
+		if deleted {
+			continue
+		}
+
+		params.Languages = append(params.Languages, language)
+		params.Entries = append(params.Entries, text)
+	}
+
+	var rows []postgres.Entry
+
+	if len(params.Entries) > 0 {
+		r, err := a.q.GetEntries(ctx, params)
+		if err != nil {
+			return fmt.Errorf("read entries from database: %w", err)
+		}
+
+		rows = r
+	}
+
+	a.m.RLock()
+	current, ok := a.phrases[language]
+	a.m.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	// A trie that is in use is only modified by the entry updater.
+	// Dictionary reloads fill the tries of new languages before they're
+	// used, and replace the tries of dropped languages, which we check for
+	// under the write lock. So we can read the current trie without the
+	// lock, and a copy of it can't miss any changes.
+
+	// The forms of the entries as they were loaded, so that they can be
+	// removed from the checker.
+	var oldForms []string
+
+	for text := range updates {
+		p, ok := current.Get(text).(*phrase)
+		if ok && p.Text == text {
+			oldForms = append(oldForms, p.Forms...)
+		}
+	}
+
+	// Large batches are staged in a copy of the trie so that checks can
+	// keep using the current trie while we work.
+	var (
+		staged *trie.RuneTrie
+		live   []postgres.Entry
+	)
+
+	if len(updates) >= entryStagingThreshold {
+		staged = cloneTrie(current)
+		live = replaceEntries(staged, updates, rows)
+	}
+
 	a.m.Lock()
 	defer a.m.Unlock()
 
-	checker, ok := a.checkers[n.Language]
+	// Cached suggestions are purged once the changes have been applied to
+	// the checkers.
+	defer a.suggestions.PurgeLanguage(language)
+
+	checker, ok := a.checkers[language]
 	if !ok {
 		return nil
 	}
 
-	trie, ok := a.phrases[n.Language]
+	phrases, ok := a.phrases[language]
 	if !ok {
 		return nil
 	}
 
-	if n.Deleted {
-		checker.Remove(n.Text)
-		trie.Delete(n.Text)
+	if staged != nil && phrases == current {
+		a.phrases[language] = staged
+	} else {
+		// Small batches are applied in place, as are batches where a
+		// dictionary reload has replaced the trie since we made the
+		// copy.
+		live = replaceEntries(phrases, updates, rows)
+	}
 
-		return nil
+	for text := range updates {
+		checker.Remove(text)
+		a.markLoaded(language, text, false)
 	}
 
-	entry, err := a.q.GetEntry(ctx, postgres.GetEntryParams{
-		Language: n.Language,
-		Entry:    n.Text,
-	})
-	if errors.Is(err, pgx.ErrNoRows) {
-		checker.Remove(n.Text)
-		trie.Delete(n.Text)
+	for _, form := range oldForms {
+		// Leave forms that are entries in their own right.
+		if a.loaded[language][form] {
+			continue
+		}
+
+		checker.Remove(form)
+	}
+
+	for _, row := range live {
+		a.registerEntry(checker, row)
+	}
+
+	return nil
+}
+
+// replaceEntries removes the updated entries and their common mistakes from a
+// phrase trie and adds the rows of the entries that still exist. Returns the
+// rows that were added.
+func replaceEntries(
+	phrases *trie.RuneTrie, updates map[string]bool, rows []postgres.Entry,
+) []postgres.Entry {
+	// Unload everything first, entries that still exist will be loaded
+	// again from the rows.
+	for text := range updates {
+		old, ok := phrases.Get(text).(*phrase)
+
+		phrases.Delete(text)
+
+		if !ok || old.Text != text {
+			continue
+		}
+
+		// Mistakes can be shared with other entries, or their folded
+		// form can be an entry, so only the keys that still lead to
+		// the old phrase are removed.
+		for _, cm := range old.CommonMistakes {
+			for _, key := range []string{cm, strings.ToLower(cm)} {
+				p, _ := phrases.Get(key).(*phrase)
+				if p == old {
+					phrases.Delete(key)
+				}
+			}
+		}
+	}
+
+	live := make([]postgres.Entry, 0, len(rows))
+
+	for _, row := range rows {
+		if putEntry(phrases, row) {
+			live = append(live, row)
+		}
+	}
+
+	return live
+}
+
+// cloneTrie returns a copy of a phrase trie. The phrases are shared, they're
+// never modified once they've been added to a trie.
+func cloneTrie(t *trie.RuneTrie) *trie.RuneTrie {
+	c := trie.NewRuneTrie()
+
+	_ = t.Walk(func(key string, value any) error {
+		c.Put(key, value)
 
 		return nil
-	} else if err != nil {
-		return fmt.Errorf("read entry from database: %w", err)
+	})
+
+	return c
+}
+
+// loadEntry adds an entry to the in-memory state, entries that are pending
+// review or have been rejected are skipped. The caller must hold the write
+// lock.
+func (a *Application) loadEntry(
+	checker *hunspell.Pool, phrases *trie.RuneTrie, row postgres.Entry,
+) {
+	if putEntry(phrases, row) {
+		a.registerEntry(checker, row)
+	}
+}
+
+// registerEntry adds a live entry to the checker, keeps track of it as
+// loaded, and makes sure that its phrases are matched. The caller must hold
+// the write lock.
+func (a *Application) registerEntry(checker *hunspell.Pool, row postgres.Entry) {
+	addEntryWords(checker, row.Entry, row.AffixModel, row.Forms)
+
+	a.markLoaded(row.Language, row.Entry, true)
+
+	segmentation := a.segmentation(row.Language)
+
+	a.growPhraseLength(row.Language, segmentation.PhraseLength(row.Entry))
+
+	for _, cm := range row.CommonMistakes {
+		a.growPhraseLength(row.Language, segmentation.PhraseLength(cm))
+	}
+}
+
+// addEntryWords adds an entry and its forms to the checker. An entry with an
+// affix model gets the affix rules of the model, so that it's inflected like
+// the model.
+func addEntryWords(
+	checker *hunspell.Pool, text string, affixModel string, forms []string,
+) {
+	if affixModel != "" {
+		checker.AddWithAffix(text, affixModel)
+	} else {
+		checker.Add(text)
+	}
+
+	for _, form := range forms {
+		checker.Add(form)
+	}
+}
+
+// putEntry adds the entry and its common mistakes to a phrase trie. Returns
+// false if the entry was skipped because it's pending review or has been
+// rejected.
+func putEntry(phrases *trie.RuneTrie, row postgres.Entry) bool {
+	if !isLiveStatus(row.Status) {
+		return false
 	}
 
 	p := phrase{
-		Text:        n.Text,
-		Description: entry.Description,
+		Text:           row.Entry,
+		Description:    row.Description,
+		Whole:          row.MatchMode == MatchModeWhole,
+		Level:          Level(row.Level),
+		Enforced:       row.Enforced,
+		Forms:          row.Forms,
+		AffixModel:     row.AffixModel,
+		CommonMistakes: row.CommonMistakes,
+		UpdatedAt:      row.UpdatedAt.Time,
+		UpdatedBy:      row.UpdatedBy,
 	}
 
-	trie.Put(n.Text, &p)
-	checker.Add(n.Text)
+	phrases.Put(row.Entry, &p)
+
+	for _, cm := range row.CommonMistakes {
+		phrases.Put(cm, &p)
 
-	for _, cm := range entry.CommonMistakes {
-		trie.Put(cm, &p)
+		// Also store the mistake case folded so that f.ex. sentence
+		// initial occurrences are caught, but never shadow an entry.
+		folded := strings.ToLower(cm)
+		if folded == cm {
+			continue
+		}
+
+		existing, ok := phrases.Get(folded).(*phrase)
+		if ok && existing.Text == folded {
+			continue
+		}
+
+		phrases.Put(folded, &p)
 	}
 
-	return nil
+	return true
+}
+
+// growPhraseLength makes sure that phrases of the given length are matched
+// for the language. The length never shrinks, so removing the longest entry
+// keeps the longer window until the next restart. The caller must hold the
+// write lock.
+func (a *Application) growPhraseLength(language string, length int) {
+	if length > a.phraseLength[language] {
+		a.phraseLength[language] = length
+	}
+}
+
+// markLoaded keeps track of which entries have been loaded into memory. The
+// caller must hold the write lock.
+func (a *Application) markLoaded(language string, text string, loaded bool) {
+	entries, ok := a.loaded[language]
+	if !ok {
+		entries = make(map[string]bool)
+		a.loaded[language] = entries
+	}
+
+	if loaded {
+		entries[text] = true
+	} else {
+		delete(entries, text)
+	}
 }
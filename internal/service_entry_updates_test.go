@@ -0,0 +1,89 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/ttab/elephant-spell/hunspell"
+	"github.com/ttab/elephant-spell/internal"
+	"github.com/ttab/elephant-spell/postgres"
+	"github.com/ttab/elephantine/test"
+)
+
+func TestReplaceEntries(t *testing.T) {
+	belarus := postgres.Entry{
+		Language:       "sv-se",
+		Entry:          "Belarus",
+		Status:         internal.StatusApproved,
+		CommonMistakes: []string{"Vitryssland", "Vitryska republiken"},
+	}
+
+	ukraina := postgres.Entry{
+		Language:       "sv-se",
+		Entry:          "Ukraina",
+		Status:         internal.StatusApproved,
+		CommonMistakes: []string{"Ukrainia"},
+	}
+
+	phrases := internal.NewPhraseTrie(belarus, ukraina)
+
+	test.Equal(t, "Belarus", phrases.Lookup("vitryssland"),
+		"match a folded mistake before the update")
+
+	// Deleting the entry leaves no rows for it.
+	phrases.Replace([]string{"Belarus"})
+
+	for _, text := range []string{
+		"Belarus", "Vitryssland", "vitryssland", "Vitryska republiken",
+	} {
+		test.Equal(t, "", phrases.Lookup(text),
+			"no match for %q after the entry was deleted", text)
+	}
+
+	test.Equal(t, "Ukraina", phrases.Lookup("Ukrainia"),
+		"keep the mistakes of other entries")
+
+	// Edited entries keep only their current mistakes.
+	ukraina.CommonMistakes = []string{"Ukrajina"}
+
+	phrases.Replace([]string{"Ukraina"}, ukraina)
+
+	test.Equal(t, "", phrases.Lookup("Ukrainia"),
+		"no match for a mistake that was removed")
+	test.Equal(t, "Ukraina", phrases.Lookup("Ukrajina"),
+		"match the new mistake")
+}
+
+func TestAddEntryWords(t *testing.T) {
+	checker, err := hunspell.NewPool(
+		"../dictionaries/sv_SE.aff",
+		"../dictionaries/sv_SE.dic",
+		1,
+	)
+	test.Must(t, err, "create checker pool")
+
+	defer checker.Close()
+
+	test.Equal(t, false, checker.Spell("Wetterbergs"),
+		"'Wetterbergs' should not be known from start")
+
+	internal.AddEntryWords(checker, postgres.Entry{
+		Language:   "sv-se",
+		Entry:      "Wetterberg",
+		AffixModel: "Svensson",
+	})
+
+	test.Equal(t, true, checker.Spell("Wetterberg"), "accept the entry")
+	test.Equal(t, true, checker.Spell("Wetterbergs"),
+		"accept the genitive, inflected like 'Svensson'")
+
+	internal.AddEntryWords(checker, postgres.Entry{
+		Language: "sv-se",
+		Entry:    "Lindqvistberg",
+		Forms:    []string{"Lindqvistbergska"},
+	})
+
+	test.Equal(t, true, checker.Spell("Lindqvistbergska"),
+		"accept the listed forms")
+	test.Equal(t, false, checker.Spell("Lindqvistbergs"),
+		"don't inflect entries without an affix model")
+}
@@ -0,0 +1,2279 @@
+package internal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/ttab/elephant-api/spell"
+	"github.com/ttab/elephant-spell/postgres"
+	"github.com/ttab/elephantine"
+	"github.com/ttab/elephantine/pg"
+	"github.com/twitchtv/twirp"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// entryRecord is the JSON representation of a custom entry used by the plain
+// HTTP endpoints. It uses the same field names as spell.CustomEntry. Updated,
+// UpdatedBy, and Deleted are only informational and are ignored when writing
+// entries. Version is only used by the conditional update. MatchMode, Level,
+// Enforced, AffixModel, and Forms aren't a part of spell.CustomEntry, empty
+// values keep the values of an existing entry when writing, but an empty list
+// of forms or an empty affix model clears them.
+type entryRecord struct {
+	Language       string   `json:"language"`
+	Text           string   `json:"text"`
+	Status         string   `json:"status"`
+	Description    string   `json:"description,omitempty"`
+	CommonMistakes []string `json:"common_mistakes,omitempty"`
+	MatchMode      string   `json:"match_mode,omitempty"`
+	Level          string   `json:"level,omitempty"`
+	Enforced       *bool    `json:"enforced,omitempty"`
+	AffixModel     *string  `json:"affix_model,omitempty"`
+	Forms          []string `json:"forms,omitempty"`
+	Updated        string   `json:"updated,omitempty"`
+	UpdatedBy      string   `json:"updated_by,omitempty"`
+	Deleted        string   `json:"deleted,omitempty"`
+	Version        string   `json:"version,omitempty"`
+}
+
+func entryRecordFromRow(row postgres.Entry) entryRecord {
+	e := entryRecord{
+		Language:       row.Language,
+		Text:           row.Entry,
+		Status:         row.Status,
+		Description:    row.Description,
+		CommonMistakes: row.CommonMistakes,
+		MatchMode:      row.MatchMode,
+		Level:          row.Level,
+		Enforced:       &row.Enforced,
+		AffixModel:     &row.AffixModel,
+		Forms:          row.Forms,
+		UpdatedBy:      row.UpdatedBy,
+		Version:        entryVersion(row),
+	}
+
+	if row.UpdatedAt.Valid {
+		e.Updated = row.UpdatedAt.Time.Format(time.RFC3339)
+	}
+
+	if row.DeletedAt.Valid {
+		e.Deleted = row.DeletedAt.Time.Format(time.RFC3339)
+	}
+
+	return e
+}
+
+// entryVersion returns the version of a stored entry, which changes every
+// time the entry is written.
+func entryVersion(row postgres.Entry) string {
+	if !row.UpdatedAt.Valid {
+		return ""
+	}
+
+	return strconv.FormatInt(row.UpdatedAt.Time.UnixMicro(), 10)
+}
+
+// Normalize normalizes the language, text, common mistakes, affix model, and
+// forms of the entry.
+func (e *entryRecord) Normalize() {
+	e.Language = NormalizeLanguage(e.Language)
+	e.Text = NormalizeEntryText(e.Text)
+
+	if e.AffixModel != nil {
+		model := NormalizeEntryText(*e.AffixModel)
+		e.AffixModel = &model
+	}
+
+	for i := range e.CommonMistakes {
+		e.CommonMistakes[i] = NormalizeEntryText(e.CommonMistakes[i])
+	}
+
+	for i := range e.Forms {
+		e.Forms[i] = NormalizeEntryText(e.Forms[i])
+	}
+}
+
+// entryRecordFromCustomEntry creates a record from an entry that was written
+// through the Twirp API. The fields that the API lacks are left empty, so that
+// the stored values are kept.
+func entryRecordFromCustomEntry(e *spell.CustomEntry) entryRecord {
+	return entryRecord{
+		Language:       e.Language,
+		Text:           e.Text,
+		Status:         e.Status,
+		Description:    e.Description,
+		CommonMistakes: e.CommonMistakes,
+	}
+}
+
+func (e entryRecord) CustomEntry() *spell.CustomEntry {
+	return &spell.CustomEntry{
+		Language:       e.Language,
+		Text:           e.Text,
+		Status:         e.Status,
+		Description:    e.Description,
+		CommonMistakes: e.CommonMistakes,
+	}
+}
+
+// Matches returns true if the record has the same values as the stored entry.
+func (e entryRecord) Matches(row postgres.Entry) bool {
+	return e.Status == row.Status &&
+		e.Description == row.Description &&
+		slices.Equal(e.CommonMistakes, row.CommonMistakes) &&
+		(e.MatchMode == "" || e.MatchMode == row.MatchMode) &&
+		(e.Level == "" || e.Level == row.Level) &&
+		(e.Enforced == nil || *e.Enforced == row.Enforced) &&
+		(e.AffixModel == nil || *e.AffixModel == row.AffixModel) &&
+		(e.Forms == nil || slices.Equal(e.Forms, row.Forms))
+}
+
+// SetEntryParams returns the parameters for writing the record. Empty match
+// modes and levels, and nil enforced flags, affix models, and forms, keep the
+// values of an existing entry.
+func (e entryRecord) SetEntryParams(updatedBy string) postgres.SetEntryParams {
+	return postgres.SetEntryParams{
+		Language:       e.Language,
+		Entry:          e.Text,
+		Status:         e.Status,
+		Description:    e.Description,
+		CommonMistakes: e.CommonMistakes,
+		UpdatedBy:      updatedBy,
+		MatchMode:      pg.TextOrNull(e.MatchMode),
+		Level:          pg.TextOrNull(e.Level),
+		Enforced:       pg.PBool(e.Enforced),
+		AffixModel:     pg.PText(e.AffixModel),
+		Forms:          e.Forms,
+	}
+}
+
+// prepareEntryRecord normalizes and validates a record before it's written.
+// All entry writes go through it, so that they're held to the same rules.
+func (a *Application) prepareEntryRecord(field string, e *entryRecord) error {
+	e.Normalize()
+
+	return a.validateEntryRecord(field, *e)
+}
+
+// validateEntryRecord validates the record as a custom entry, field is used as
+// the prefix for the argument names in the returned errors.
+func (a *Application) validateEntryRecord(field string, e entryRecord) error {
+	err := a.validateEntry(field, e.CustomEntry())
+	if err != nil {
+		return err
+	}
+
+	err = validateMatchMode(field+".match_mode", e.MatchMode)
+	if err != nil {
+		return err
+	}
+
+	err = validateLevel(field+".level", e.Level)
+	if err != nil {
+		return err
+	}
+
+	err = a.validateAffixModel(field+".affix_model", e.Language, e.AffixModel)
+	if err != nil {
+		return err
+	}
+
+	return validateForms(field+".forms", a.segmentation(e.Language), e.Forms)
+}
+
+// validateAffixModel checks that the affix model of an entry is a single word
+// that the dictionary knows, hunspell can only borrow the affix rules of a
+// word that it has rules for. Nil and empty models are valid.
+func (a *Application) validateAffixModel(
+	field string, language string, model *string,
+) error {
+	if model == nil || *model == "" {
+		return nil
+	}
+
+	if a.segmentation(language).PhraseLength(*model) != 1 {
+		return twirp.InvalidArgumentError(field, "must be a single word")
+	}
+
+	checker, _, release, err := a.checkerForLanguage(language)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	if !checker.Spell(*model) {
+		return twirp.InvalidArgumentError(field,
+			"must be a word in the dictionary")
+	}
+
+	return nil
+}
+
+// validateForms checks that the forms of an entry are single words and that
+// no form is listed twice, so that mistakes in the input are reported instead
+// of being dropped.
+func validateForms(
+	field string, segmentation Segmentation, forms []string,
+) error {
+	seen := make(map[string]int, len(forms))
+
+	for i, form := range forms {
+		name := fmt.Sprintf("%s[%d]", field, i)
+
+		if form == "" {
+			return twirp.InvalidArgumentError(name, "must not be empty")
+		}
+
+		if segmentation.PhraseLength(form) != 1 {
+			return twirp.InvalidArgumentError(name, "must be a single word")
+		}
+
+		first, ok := seen[form]
+		if ok {
+			return twirp.InvalidArgumentError(name, fmt.Sprintf(
+				"duplicate of %s[%d]", field, first))
+		}
+
+		seen[form] = i
+	}
+
+	return nil
+}
+
+// registerHTTPHandlers adds the endpoints that don't fit the request/response
+// model of the Twirp services.
+func (a *Application) registerHTTPHandlers(mux *http.ServeMux) {
+	mux.Handle("GET /entries", a.httpHandler(a.listEntries))
+	mux.Handle("GET /entries/count", a.httpHandler(a.countEntries))
+	mux.Handle("GET /entries/stream", a.httpHandler(a.streamEntries))
+	mux.Handle("GET /dictionaries/stats", a.httpHandler(a.dictionaryStats))
+	mux.Handle("GET /dictionaries/gaps", a.httpHandler(a.spellingGaps))
+	mux.Handle("GET /dictionaries/{language}/export",
+		a.httpHandler(a.exportDictionary))
+	mux.Handle("POST /dictionaries/reload",
+		a.httpHandler(a.reloadDictionariesHandler))
+	mux.Handle("POST /check/text", a.httpHandler(a.checkText))
+	mux.Handle("POST /check/text/stream", a.httpHandler(a.checkTextStream))
+	mux.Handle("POST /check/suggest", a.httpHandler(a.suggestPhrase))
+	mux.Handle("POST /entries/forms", a.httpHandler(a.suggestForms))
+	mux.Handle("POST /check/debug", a.httpHandler(a.debugCheck))
+	mux.Handle("POST /check/explain", a.httpHandler(a.explainCheck))
+	mux.Handle("POST /check/detect", a.httpHandler(a.detectTextLanguage))
+	mux.Handle("GET /check/languages", a.httpHandler(a.listLanguages))
+	mux.Handle("POST /entries/preflight", a.httpHandler(a.preflightEntries))
+	mux.Handle("POST /entries/bulk", a.httpHandler(a.bulkSetEntries))
+	mux.Handle("POST /entries/restore", a.httpHandler(a.restoreEntry))
+	mux.Handle("POST /entries/delete-prefix",
+		a.httpHandler(a.deleteEntriesByPrefix))
+	mux.Handle("GET /entries/history", a.httpHandler(a.entryHistory))
+	mux.Handle("GET /entries/similar", a.httpHandler(a.similarEntries))
+	mux.Handle("GET /entry", a.httpHandler(a.getEntry))
+	mux.Handle("PUT /entry", a.httpHandler(a.updateEntry))
+	mux.Handle("POST /entry/merge", a.httpHandler(a.mergeEntry))
+	mux.Handle("POST /entries/propose", a.httpHandler(a.proposeEntry))
+	mux.Handle("POST /entries/approve", a.httpHandler(a.approveEntry))
+	mux.Handle("POST /entries/reject", a.httpHandler(a.rejectEntry))
+}
+
+// httpHandler authenticates the request the same way as our Twirp services
+// and responds with a Twirp error if the handler fails.
+func (a *Application) httpHandler(
+	fn func(w http.ResponseWriter, r *http.Request) error,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, err := a.p.AuthInfoParser.AuthInfoFromHeader(
+			r.Header.Get("Authorization"))
+
+		switch {
+		case errors.Is(err, elephantine.ErrNoAuthorization):
+			_ = twirp.WriteError(w, twirp.Unauthenticated.Error(
+				"authentication required"))
+
+			return
+		case err != nil:
+			_ = twirp.WriteError(w, twirp.PermissionDenied.Errorf(
+				"invalid authorization: %v", err))
+
+			return
+		}
+
+		ctx := elephantine.SetAuthInfo(r.Context(), auth)
+
+		err = fn(w, r.WithContext(ctx))
+		if err != nil {
+			a.logger.ErrorContext(ctx, "HTTP request failed",
+				elephantine.LogKeyError, err,
+				"path", r.URL.Path)
+
+			_ = twirp.WriteError(w, err)
+		}
+	})
+}
+
+type countEntriesResponse struct {
+	Total int64 `json:"total"`
+}
+
+// countEntries returns the number of entries matching the same query
+// parameters as listEntries.
+func (a *Application) countEntries(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckWrite)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	query := r.URL.Query()
+
+	pattern := prefixPattern(query.Get("prefix"))
+	mistake := containsPattern(query.Get("mistake"))
+
+	total, err := a.q.CountEntries(ctx, postgres.CountEntriesParams{
+		Language:       pg.TextOrNull(NormalizeLanguage(query.Get("language"))),
+		Pattern:        pg.TextOrNull(pattern),
+		Status:         pg.TextOrNull(query.Get("status")),
+		Mistake:        pg.TextOrNull(mistake),
+		IncludeDeleted: query.Get("include_deleted") == "true",
+	})
+	if err != nil {
+		return twirp.InternalErrorf("count entries: %w", err)
+	}
+
+	return writeJSON(w, countEntriesResponse{
+		Total: total,
+	})
+}
+
+// streamEntries writes all entries matching the language, prefix, and status
+// query parameters as newline delimited JSON.
+func (a *Application) streamEntries(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckWrite)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	query := r.URL.Query()
+
+	pattern := prefixPattern(query.Get("prefix"))
+
+	params := postgres.IterateEntriesParams{
+		Language: pg.TextOrNull(NormalizeLanguage(query.Get("language"))),
+		Pattern:  pg.TextOrNull(pattern),
+		Status:   pg.TextOrNull(query.Get("status")),
+	}
+
+	return a.writeEntryStream(w, r, params, nil)
+}
+
+// entryCursor is the position after the last returned entry. It's handed to
+// clients as an opaque token.
+type entryCursor struct {
+	Language string `json:"l"`
+	Entry    string `json:"e"`
+}
+
+func (c entryCursor) Token() string {
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func parseEntryCursor(token string) (entryCursor, error) {
+	var c entryCursor
+
+	if token == "" {
+		return c, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, errors.New("invalid cursor")
+	}
+
+	err = json.Unmarshal(data, &c)
+	if err != nil {
+		return c, errors.New("invalid cursor")
+	}
+
+	return c, nil
+}
+
+type listEntriesResponse struct {
+	Entries []entryRecord `json:"entries"`
+	Cursor  string        `json:"cursor,omitempty"`
+}
+
+// listEntries returns a page of entries matching the language, prefix,
+// status, and mistake query parameters. Deleted entries are included if
+// include_deleted is "true". Pass the returned cursor to get the next page,
+// the cursor is omitted when there are no more entries.
+func (a *Application) listEntries(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckWrite)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	query := r.URL.Query()
+
+	pattern := prefixPattern(query.Get("prefix"))
+	mistake := containsPattern(query.Get("mistake"))
+
+	cursor, err := parseEntryCursor(query.Get("cursor"))
+	if err != nil {
+		return twirp.InvalidArgumentError("cursor", err.Error())
+	}
+
+	limit := int64(100)
+
+	rows, err := a.q.IterateEntries(ctx, postgres.IterateEntriesParams{
+		Language:       pg.TextOrNull(NormalizeLanguage(query.Get("language"))),
+		Pattern:        pg.TextOrNull(pattern),
+		Status:         pg.TextOrNull(query.Get("status")),
+		Mistake:        pg.TextOrNull(mistake),
+		IncludeDeleted: query.Get("include_deleted") == "true",
+		AfterLanguage:  cursor.Language,
+		AfterEntry:     cursor.Entry,
+		Limit:          limit,
+	})
+	if err != nil {
+		return twirp.InternalErrorf("read from database: %w", err)
+	}
+
+	res := listEntriesResponse{
+		Entries: make([]entryRecord, len(rows)),
+	}
+
+	for i, row := range rows {
+		res.Entries[i] = entryRecordFromRow(row)
+	}
+
+	if int64(len(rows)) == limit {
+		last := rows[len(rows)-1]
+
+		res.Cursor = entryCursor{
+			Language: last.Language,
+			Entry:    last.Entry,
+		}.Token()
+	}
+
+	return writeJSON(w, res)
+}
+
+type exportHeader struct {
+	Language string `json:"language"`
+	Total    int64  `json:"total"`
+}
+
+// exportDictionary writes all entries for a language as newline delimited
+// JSON, ordered by entry text. The first line is a header with the language
+// and the total number of entries.
+func (a *Application) exportDictionary(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	language := r.PathValue("language")
+
+	langCode := NormalizeLanguage(language)
+	if !a.isSupportedLanguage(langCode) {
+		return a.unsupportedLanguageError("language", language)
+	}
+
+	_, err := requireWriteAccess(ctx, langCode)
+	if err != nil {
+		return err
+	}
+
+	total, err := a.q.CountEntries(ctx, postgres.CountEntriesParams{
+		Language: pg.Text(langCode),
+	})
+	if err != nil {
+		return twirp.InternalErrorf("count entries: %w", err)
+	}
+
+	params := postgres.IterateEntriesParams{
+		Language: pg.Text(langCode),
+	}
+
+	header := exportHeader{
+		Language: langCode,
+		Total:    total,
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(
+		"attachment; filename=%q", langCode+".jsonl"))
+
+	return a.writeEntryStream(w, r, params, header)
+}
+
+// writeEntryStream writes the entries matched by params as newline delimited
+// JSON, preceded by the header if it's non-nil. The entries are read in pages
+// using keyset pagination so that we never hold the full result set in memory.
+func (a *Application) writeEntryStream(
+	w http.ResponseWriter, r *http.Request,
+	params postgres.IterateEntriesParams, header any,
+) error {
+	ctx := r.Context()
+
+	params.Limit = 200
+
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+
+	var started bool
+
+	for {
+		rows, err := a.q.IterateEntries(ctx, params)
+		if err != nil && started {
+			// Too late to report the error to the client, the
+			// truncated stream will have to do.
+			a.logger.ErrorContext(ctx, "failed to read next page of entries",
+				elephantine.LogKeyError, err)
+
+			return nil
+		} else if err != nil {
+			return twirp.InternalErrorf("read from database: %w", err)
+		}
+
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+
+			started = true
+
+			if header != nil {
+				err := enc.Encode(header)
+				if err != nil {
+					// The client has most likely gone away.
+					return nil
+				}
+			}
+		}
+
+		for _, row := range rows {
+			err := enc.Encode(entryRecordFromRow(row))
+			if err != nil {
+				// The client has most likely gone away.
+				return nil
+			}
+		}
+
+		if int64(len(rows)) < params.Limit {
+			return nil
+		}
+
+		err = rc.Flush()
+		if err != nil {
+			return nil
+		}
+
+		last := rows[len(rows)-1]
+
+		params.AfterLanguage = last.Language
+		params.AfterEntry = last.Entry
+	}
+}
+
+type dictionaryStats struct {
+	Language string           `json:"language"`
+	Total    int64            `json:"total"`
+	Statuses map[string]int64 `json:"statuses"`
+	Levels   map[string]int64 `json:"levels"`
+}
+
+type dictionaryStatsResponse struct {
+	Dictionaries []*dictionaryStats `json:"dictionaries"`
+}
+
+// dictionaryStats reports the number of entries per status and per level for
+// every language that has entries.
+func (a *Application) dictionaryStats(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckWrite)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	rows, err := a.q.GetDictionaryStats(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("read from database: %w", err)
+	}
+
+	var (
+		res     dictionaryStatsResponse
+		current *dictionaryStats
+	)
+
+	// The rows are ordered by language.
+	for _, row := range rows {
+		if current == nil || current.Language != row.Language {
+			current = &dictionaryStats{
+				Language: row.Language,
+				Statuses: make(map[string]int64),
+				Levels:   make(map[string]int64),
+			}
+
+			res.Dictionaries = append(res.Dictionaries, current)
+		}
+
+		current.Total += row.Entries
+		current.Statuses[row.Status] += row.Entries
+		current.Levels[row.Level] += row.Entries
+	}
+
+	return writeJSON(w, res)
+}
+
+type reloadDictionariesResponse struct {
+	Languages []string `json:"languages"`
+}
+
+// reloadDictionariesHandler loads the base dictionaries again, f.ex. after
+// they have been replaced in the dictionary directory.
+func (a *Application) reloadDictionariesHandler(
+	w http.ResponseWriter, r *http.Request,
+) error {
+	ctx := r.Context()
+
+	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckAdmin)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	languages, err := a.reloadDictionaries(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("reload dictionaries: %w", err)
+	}
+
+	return writeJSON(w, reloadDictionariesResponse{
+		Languages: languages,
+	})
+}
+
+type checkTextRequest struct {
+	Language     string   `json:"language"`
+	Text         []string `json:"text"`
+	Ignore       []string `json:"ignore"`
+	CheckNumbers bool     `json:"check_numbers"`
+	// WithSuggestions defaults to true.
+	WithSuggestions *bool `json:"with_suggestions"`
+	// Glossary is the name of a glossary of the language to check
+	// against in addition to the dictionary.
+	Glossary string `json:"glossary"`
+	// MaxSuggestions limits the number of suggestions per misspelled
+	// word, the server limit applies if it's smaller.
+	MaxSuggestions int `json:"max_suggestions"`
+}
+
+type checkTextResponse struct {
+	Language   string        `json:"language"`
+	Misspelled []checkedText `json:"misspelled"`
+}
+
+type checkedText struct {
+	Entries []checkedEntry `json:"entries"`
+	// Accepted lists the custom entries that words or phrases in the
+	// text were accepted by.
+	Accepted []AcceptedEntry `json:"accepted,omitempty"`
+}
+
+// checkedEntry is a misspelled entry together with the source of the
+// suggestions and the level of the entry. The suggestions replace the ones of
+// the misspelled entry to add a score, which spell.Suggestion has no field
+// for.
+type checkedEntry struct {
+	*spell.MisspelledEntry
+
+	Suggestions []scoredSuggestion `json:"suggestions"`
+	Source      EntrySource        `json:"source"`
+	Level       Level              `json:"level"`
+	// Enforced is true if the entry is a common mistake of an enforced
+	// entry, which always is flagged as an error.
+	Enforced bool `json:"enforced,omitempty"`
+	// Ranges are the occurrences of the entry in the text.
+	Ranges []TextRange `json:"ranges,omitempty"`
+}
+
+// scoredSuggestion is a suggestion with a score that clients can sort mixed
+// suggestion lists by, higher is better.
+type scoredSuggestion struct {
+	Text        string  `json:"text"`
+	Description string  `json:"description,omitempty"`
+	Score       float64 `json:"score"`
+}
+
+// checkText works like the Text method of the Check service, but also
+// accepts a list of words that shouldn't be flagged in the texts.
+func (a *Application) checkText(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	auth, ok := elephantine.GetAuthInfo(ctx)
+	if !ok {
+		return twirp.Unauthenticated.Error("unauthenticated")
+	}
+
+	var req checkTextRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	if req.MaxSuggestions < 0 {
+		return twirp.InvalidArgumentError("max_suggestions",
+			"must not be negative")
+	}
+
+	err = a.limiter.Allow(auth.Claims.Subject, req.Text...)
+	if err != nil {
+		return err
+	}
+
+	res, details, langCode, err := a.checkTexts(req.Language, req.Text, checkOptions{
+		Ignore:          newIgnoreList(req.Ignore),
+		CheckNumbers:    req.CheckNumbers,
+		SkipSuggestions: req.WithSuggestions != nil && !*req.WithSuggestions,
+		Glossary:        req.Glossary,
+		MaxSuggestions:  req.MaxSuggestions,
+	})
+	if err != nil {
+		return err
+	}
+
+	out := checkTextResponse{
+		Language:   langCode,
+		Misspelled: make([]checkedText, len(res.Misspelled)),
+	}
+
+	for i, m := range res.Misspelled {
+		out.Misspelled[i] = a.checkedText(langCode, m, details[i])
+	}
+
+	return writeJSON(w, out)
+}
+
+// checkedText adds the source of the suggestions, and the suggestion scores,
+// to the misspelled entries.
+func (a *Application) checkedText(
+	langCode string, m *spell.Misspelled, details checkDetails,
+) checkedText {
+	entries := make([]checkedEntry, len(m.Entries))
+
+	for i, e := range m.Entries {
+		source := a.entrySource(langCode, e.Text)
+
+		level := LevelError
+		if details.SuggestionLevel[e.Text] {
+			level = LevelSuggestion
+		}
+
+		entries[i] = checkedEntry{
+			MisspelledEntry: e,
+			Suggestions:     scoreSuggestions(source, e.Suggestions),
+			Source:          source,
+			Level:           level,
+			Enforced:        details.Enforced[e.Text],
+			Ranges:          details.Ranges[e.Text],
+		}
+	}
+
+	return checkedText{
+		Entries:  entries,
+		Accepted: details.Accepted,
+	}
+}
+
+// scoreSuggestions scores the suggestions of a misspelled entry. The
+// correction of a common mistake is always the first suggestion, the rest
+// are scored by their hunspell rank.
+func scoreSuggestions(
+	source EntrySource, suggestions []*spell.Suggestion,
+) []scoredSuggestion {
+	scored := make([]scoredSuggestion, len(suggestions))
+
+	var rank int
+
+	for i, s := range suggestions {
+		scored[i] = scoredSuggestion{
+			Text:        s.Text,
+			Description: s.Description,
+		}
+
+		if i == 0 && source == SourceCommonMistake {
+			scored[i].Score = CustomSuggestionScore
+
+			continue
+		}
+
+		scored[i].Score = HunspellSuggestionScore(rank)
+		rank++
+	}
+
+	return scored
+}
+
+// streamError is written as the last line of a stream that fails after the
+// response has been started, in the same format as a Twirp error.
+type streamError struct {
+	Error streamErrorBody `json:"error"`
+}
+
+type streamErrorBody struct {
+	Code twirp.ErrorCode   `json:"code"`
+	Msg  string            `json:"msg"`
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+func newStreamError(err error) streamError {
+	var tErr twirp.Error
+
+	if !errors.As(err, &tErr) {
+		tErr = twirp.InternalErrorWith(err)
+	}
+
+	return streamError{
+		Error: streamErrorBody{
+			Code: tErr.Code(),
+			Msg:  tErr.Msg(),
+			Meta: tErr.MetaMap(),
+		},
+	}
+}
+
+// checkTextStream checks a text that is sent as a stream of newline delimited
+// JSON chunks, and writes the result for every chunk as soon as it has been
+// checked. Large documents never have to be held in memory in full.
+func (a *Application) checkTextStream(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	auth, ok := elephantine.GetAuthInfo(ctx)
+	if !ok {
+		return twirp.Unauthenticated.Error("unauthenticated")
+	}
+
+	err := a.limiter.Allow(auth.Claims.Subject)
+	if err != nil {
+		return err
+	}
+
+	query := r.URL.Query()
+
+	language := query.Get("language")
+	if isAutoLanguage(language) {
+		return twirp.InvalidArgumentError("language",
+			"language detection isn't supported for streamed texts")
+	}
+
+	checker, langCode, release, err := a.checkerForLanguage(language)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	opts := checkOptions{
+		SkipSuggestions: query.Get("with_suggestions") == "false",
+		Glossary:        query.Get("glossary"),
+	}
+
+	checker, err = glossaryChecker(checker, opts.Glossary)
+	if err != nil {
+		return err
+	}
+
+	a.metrics.textRequests.WithLabelValues(langCode).Inc()
+
+	rc := http.NewResponseController(w)
+
+	// Let us write results while the client is still sending chunks.
+	err = rc.EnableFullDuplex()
+	if err != nil {
+		return twirp.InternalErrorf("enable full duplex: %w", err)
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+
+	// The chunks are validated against the text limits before they're
+	// checked, the rate limiter alone wouldn't stop a single huge chunk.
+	for text, err := range a.textLimits.Chunks(r.Body) {
+		if err != nil {
+			// The response has already been started, so all we can
+			// do is to end the stream with an error line.
+			a.logger.WarnContext(ctx, "invalid text chunk",
+				elephantine.LogKeyError, err)
+
+			_ = enc.Encode(newStreamError(err))
+
+			return nil
+		}
+
+		// Throttle the stream rather than failing it midway.
+		err = a.limiter.WaitWords(ctx, auth.Claims.Subject, text)
+		if err != nil {
+			return nil
+		}
+
+		var details checkDetails
+
+		res := a.spellcheck(text, checker, langCode, opts, nil, &details)
+
+		err = enc.Encode(a.checkedText(langCode, res, details))
+		if err != nil {
+			// The client has most likely gone away.
+			return nil
+		}
+
+		err = rc.Flush()
+		if err != nil {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+type suggestFormsRequest struct {
+	Language string   `json:"language"`
+	Text     string   `json:"text"`
+	Examples []string `json:"examples"`
+}
+
+type suggestFormsResponse struct {
+	Forms []string `json:"forms"`
+}
+
+// suggestForms generates the likely inflections of a word, using inflected
+// example words with the same declension as models.
+func (a *Application) suggestForms(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckWrite)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	var req suggestFormsRequest
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	if req.Text == "" {
+		return twirp.RequiredArgumentError("text")
+	}
+
+	if len(req.Examples) == 0 {
+		return twirp.RequiredArgumentError("examples")
+	}
+
+	checker, _, release, err := a.checkerForLanguage(req.Language)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	res := suggestFormsResponse{
+		Forms: []string{},
+	}
+
+	for _, example := range req.Examples {
+		for _, form := range checker.Generate(req.Text, example) {
+			if form == req.Text || slices.Contains(res.Forms, form) {
+				continue
+			}
+
+			res.Forms = append(res.Forms, form)
+		}
+	}
+
+	return writeJSON(w, res)
+}
+
+type suggestionsRequest struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+type suggestionsResponse struct {
+	Misspelled  bool                `json:"misspelled"`
+	Suggestions []*spell.Suggestion `json:"suggestions"`
+}
+
+// suggestPhrase returns the suggestions for a single word or phrase.
+func (a *Application) suggestPhrase(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, ok := elephantine.GetAuthInfo(ctx)
+	if !ok {
+		return twirp.Unauthenticated.Error("unauthenticated")
+	}
+
+	var req suggestionsRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	if req.Text == "" {
+		return twirp.RequiredArgumentError("text")
+	}
+
+	checker, langCode, release, err := a.checkerForLanguage(req.Language)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	suggestions, misspelled := a.suggest(req.Text, checker, langCode)
+
+	return writeJSON(w, suggestionsResponse{
+		Misspelled:  misspelled,
+		Suggestions: suggestions,
+	})
+}
+
+type debugCheckRequest struct {
+	Language     string   `json:"language"`
+	Text         []string `json:"text"`
+	Ignore       []string `json:"ignore"`
+	CheckNumbers bool     `json:"check_numbers"`
+}
+
+type debugCheckResponse struct {
+	Texts []*checkTrace `json:"texts"`
+}
+
+// debugCheck runs a spellcheck and reports the verdict for every word and
+// phrase that was considered, including the ones that weren't flagged.
+func (a *Application) debugCheck(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckAdmin)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	var req debugCheckRequest
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	checker, langCode, release, err := a.checkerForLanguage(req.Language)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	res := debugCheckResponse{
+		Texts: make([]*checkTrace, len(req.Text)),
+	}
+
+	for i := range req.Text {
+		var trace checkTrace
+
+		_ = a.spellcheck(req.Text[i], checker, langCode, checkOptions{
+			Ignore:       newIgnoreList(req.Ignore),
+			CheckNumbers: req.CheckNumbers,
+		}, &trace, nil)
+
+		res.Texts[i] = &trace
+	}
+
+	return writeJSON(w, res)
+}
+
+type explainRequest struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+	Glossary string `json:"glossary"`
+}
+
+type explainResponse struct {
+	Language string `json:"language"`
+	// Text is the text after normalization.
+	Text string `json:"text"`
+	// Phrase is the custom entry that the text matched, if any.
+	Phrase *explainedPhrase `json:"phrase,omitempty"`
+	// Loaded is true if the text is a loaded custom entry.
+	Loaded bool            `json:"loaded"`
+	Words  []explainedWord `json:"words"`
+	// Tokens are the verdicts of a check of the text.
+	Tokens []TokenVerdict `json:"tokens"`
+	// Entries are the flagged entries of a check of the text.
+	Entries []checkedEntry `json:"entries"`
+}
+
+type explainedPhrase struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description,omitempty"`
+	// CommonMistake is true if the text matched a common mistake of the
+	// entry rather than the entry itself.
+	CommonMistake bool `json:"common_mistake"`
+	// Folded is true if the text only matched a common mistake when case
+	// folded.
+	Folded   bool  `json:"folded"`
+	Whole    bool  `json:"whole"`
+	Level    Level `json:"level"`
+	Enforced bool  `json:"enforced"`
+	// AffixModel is the dictionary word that the entry is inflected
+	// like.
+	AffixModel string    `json:"affix_model,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	UpdatedBy  string    `json:"updated_by"`
+}
+
+type explainedWord struct {
+	Text        string   `json:"text"`
+	Spelled     bool     `json:"spelled"`
+	Stems       []string `json:"stems"`
+	Analysis    []string `json:"analysis"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// explainCheck explains how a single word or phrase is handled by a check:
+// the custom entry that it matches, what hunspell makes of its words, and
+// the outcome of checking it.
+func (a *Application) explainCheck(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckAdmin)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	var req explainRequest
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	text := NormalizeEntryText(req.Text)
+	if text == "" {
+		return twirp.RequiredArgumentError("text")
+	}
+
+	checker, langCode, release, err := a.checkerForLanguage(req.Language)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	checker, err = glossaryChecker(checker, req.Glossary)
+	if err != nil {
+		return err
+	}
+
+	res := explainResponse{
+		Language: langCode,
+		Text:     text,
+	}
+
+	a.m.RLock()
+
+	p, folded := lookupPhrase(a.phrases[langCode], text)
+	res.Loaded = a.loaded[langCode][text]
+
+	a.m.RUnlock()
+
+	if p != nil {
+		res.Phrase = &explainedPhrase{
+			Entry:         p.Text,
+			Description:   p.Description,
+			CommonMistake: p.Text != text,
+			Folded:        folded,
+			Whole:         p.Whole,
+			Level:         p.Level,
+			Enforced:      p.Enforced,
+			AffixModel:    p.AffixModel,
+			UpdatedAt:     p.UpdatedAt,
+			UpdatedBy:     p.UpdatedBy,
+		}
+	}
+
+	segmentation := a.segmentation(langCode)
+
+	for word := range segmentation.Words([]byte(text)) {
+		res.Words = append(res.Words, explainedWord{
+			Text:        word,
+			Spelled:     checker.Spell(word),
+			Stems:       checker.Stem(word),
+			Analysis:    checker.Analyze(word),
+			Suggestions: checker.Suggest(word),
+		})
+	}
+
+	var (
+		trace   checkTrace
+		details checkDetails
+	)
+
+	checked := a.spellcheck(text, checker, langCode, checkOptions{
+		Glossary: req.Glossary,
+	}, &trace, &details)
+
+	res.Tokens = trace.Tokens
+	res.Entries = a.checkedText(langCode, checked, details).Entries
+
+	return writeJSON(w, res)
+}
+
+type languageInfo struct {
+	Code       string   `json:"code"`
+	Name       string   `json:"name,omitempty"`
+	Glossaries []string `json:"glossaries,omitempty"`
+}
+
+type listLanguagesResponse struct {
+	Languages []languageInfo `json:"languages"`
+}
+
+// listLanguages returns the languages that texts can be checked in, with
+// their names in the language itself.
+func (a *Application) listLanguages(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, ok := elephantine.GetAuthInfo(ctx)
+	if !ok {
+		return twirp.Unauthenticated.Error("unauthenticated")
+	}
+
+	codes := a.languageCodes()
+
+	res := listLanguagesResponse{
+		Languages: make([]languageInfo, len(codes)),
+	}
+
+	a.m.RLock()
+
+	for i, code := range codes {
+		res.Languages[i] = languageInfo{
+			Code: code,
+			Name: languageName(code),
+		}
+
+		checker, ok := a.checkers[code]
+		if ok {
+			res.Languages[i].Glossaries = checker.Glossaries()
+		}
+	}
+
+	a.m.RUnlock()
+
+	return writeJSON(w, res)
+}
+
+// languageName returns the name of the language in the language itself, f.ex.
+// "svenska (Sverige)" for "sv-se".
+func languageName(code string) string {
+	tag, err := language.Parse(code)
+	if err != nil {
+		return ""
+	}
+
+	return display.Self.Name(tag)
+}
+
+type detectLanguageRequest struct {
+	Text []string `json:"text"`
+}
+
+type detectLanguageResponse struct {
+	Language string          `json:"language"`
+	Scores   []LanguageScore `json:"scores"`
+}
+
+// detectTextLanguage reports the supported language that best matches the
+// text, and the scores of all the supported languages.
+func (a *Application) detectTextLanguage(
+	w http.ResponseWriter, r *http.Request,
+) error {
+	ctx := r.Context()
+
+	_, ok := elephantine.GetAuthInfo(ctx)
+	if !ok {
+		return twirp.Unauthenticated.Error("unauthenticated")
+	}
+
+	var req detectLanguageRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	scores, err := a.detectLanguage(req.Text)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(w, detectLanguageResponse{
+		Language: scores[0].Language,
+		Scores:   scores,
+	})
+}
+
+type entriesRequest struct {
+	Entries []entryRecord `json:"entries"`
+}
+
+type preflightResponse struct {
+	Total     int `json:"total"`
+	New       int `json:"new"`
+	Changed   int `json:"changed"`
+	Unchanged int `json:"unchanged"`
+}
+
+// preflightEntries validates a set of entries and reports how many of them
+// would be created, changed, or left as they are if they were written. Nothing
+// is written to the database.
+func (a *Application) preflightEntries(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, ok := elephantine.GetAuthInfo(ctx)
+	if !ok {
+		return twirp.Unauthenticated.Error("unauthenticated")
+	}
+
+	var req entriesRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	params := postgres.GetEntriesParams{
+		Languages: make([]string, len(req.Entries)),
+		Entries:   make([]string, len(req.Entries)),
+	}
+
+	for i := range req.Entries {
+		err := a.prepareEntryRecord(
+			fmt.Sprintf("entries[%d]", i), &req.Entries[i])
+		if err != nil {
+			return err
+		}
+
+		e := req.Entries[i]
+
+		_, err = requireWriteAccess(ctx, e.Language)
+		if err != nil {
+			return err
+		}
+
+		params.Languages[i] = e.Language
+		params.Entries[i] = e.Text
+	}
+
+	rows, err := a.q.GetEntries(ctx, params)
+	if err != nil {
+		return twirp.InternalErrorf("read from database: %w", err)
+	}
+
+	type entryKey struct {
+		Language string
+		Text     string
+	}
+
+	existing := make(map[entryKey]postgres.Entry, len(rows))
+
+	for _, row := range rows {
+		existing[entryKey{row.Language, row.Entry}] = row
+	}
+
+	res := preflightResponse{
+		Total: len(req.Entries),
+	}
+
+	for _, e := range req.Entries {
+		row, ok := existing[entryKey{e.Language, e.Text}]
+
+		switch {
+		case !ok:
+			res.New++
+		case e.Matches(row):
+			res.Unchanged++
+		default:
+			res.Changed++
+		}
+	}
+
+	return writeJSON(w, res)
+}
+
+type bulkSetResponse struct {
+	Written int `json:"written"`
+}
+
+// bulkSetEntries writes a set of entries in a single transaction. Either all
+// entries are written, or none of them.
+func (a *Application) bulkSetEntries(
+	w http.ResponseWriter, r *http.Request,
+) (outErr error) {
+	ctx := r.Context()
+
+	auth, ok := elephantine.GetAuthInfo(ctx)
+	if !ok {
+		return twirp.Unauthenticated.Error("unauthenticated")
+	}
+
+	var req entriesRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	if len(req.Entries) == 0 {
+		return twirp.RequiredArgumentError("entries")
+	}
+
+	// Validate everything before we start writing.
+	for i := range req.Entries {
+		err := a.prepareEntryRecord(
+			fmt.Sprintf("entries[%d]", i), &req.Entries[i])
+		if err != nil {
+			return err
+		}
+
+		_, err = requireWriteAccess(ctx, req.Entries[i].Language)
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("start transaction: %w", err)
+	}
+
+	defer pg.Rollback(tx, &outErr)
+
+	q := a.queries(tx)
+
+	updated := make(map[string][]string)
+
+	for i, e := range req.Entries {
+		err := setEntryWithHistory(ctx, q,
+			e.SetEntryParams(auth.Claims.Subject))
+		if err != nil {
+			return twirp.InternalErrorf(
+				"write entries[%d] to database: %w", i, err)
+		}
+
+		updated[e.Language] = append(updated[e.Language], e.Text)
+	}
+
+	for language, texts := range updated {
+		err := notifyEntriesUpdated(ctx, q, language, texts, false)
+		if err != nil {
+			return twirp.InternalErrorf("send notification: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("commit changes: %w", err)
+	}
+
+	return writeJSON(w, bulkSetResponse{
+		Written: len(req.Entries),
+	})
+}
+
+type restoreEntryRequest struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+// restoreEntry restores a deleted entry.
+func (a *Application) restoreEntry(
+	w http.ResponseWriter, r *http.Request,
+) (outErr error) {
+	ctx := r.Context()
+
+	var req restoreEntryRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	if req.Language == "" {
+		return twirp.RequiredArgumentError("language")
+	}
+
+	if req.Text == "" {
+		return twirp.RequiredArgumentError("text")
+	}
+
+	req.Language = NormalizeLanguage(req.Language)
+	req.Text = NormalizeEntryText(req.Text)
+
+	auth, err := requireWriteAccess(ctx, req.Language)
+	if err != nil {
+		return err
+	}
+
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("start transaction: %w", err)
+	}
+
+	defer pg.Rollback(tx, &outErr)
+
+	q := a.queries(tx)
+
+	restored, err := restoreEntryWithHistory(ctx, q, postgres.RestoreEntryParams{
+		UpdatedBy: auth.Claims.Subject,
+		Language:  req.Language,
+		Entry:     req.Text,
+	})
+	if err != nil {
+		return twirp.InternalErrorf("write to database: %w", err)
+	}
+
+	if !restored {
+		return twirp.NotFoundError("no deleted entry to restore")
+	}
+
+	err = notifyEntryUpdated(ctx, q, EntryUpdateNotification{
+		Language: req.Language,
+		Text:     req.Text,
+	})
+	if err != nil {
+		return twirp.InternalErrorf("send notification: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("commit changes: %w", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+type spellingGap struct {
+	Language    string `json:"language"`
+	Word        string `json:"word"`
+	Occurrences int64  `json:"occurrences"`
+	FirstSeen   string `json:"first_seen"`
+	LastSeen    string `json:"last_seen"`
+}
+
+type spellingGapsResponse struct {
+	Gaps []spellingGap `json:"gaps"`
+}
+
+// spellingGaps lists the most common misspelled words that hunspell had no
+// suggestions for, leaving out words that have been added as entries since.
+func (a *Application) spellingGaps(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckWrite)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	query := r.URL.Query()
+
+	rows, err := a.q.ListSpellingGaps(ctx, postgres.ListSpellingGapsParams{
+		Language: pg.TextOrNull(NormalizeLanguage(query.Get("language"))),
+		Limit:    100,
+	})
+	if err != nil {
+		return twirp.InternalErrorf("read from database: %w", err)
+	}
+
+	res := spellingGapsResponse{
+		Gaps: make([]spellingGap, len(rows)),
+	}
+
+	for i, row := range rows {
+		res.Gaps[i] = spellingGap{
+			Language:    row.Language,
+			Word:        row.Word,
+			Occurrences: row.Occurrences,
+			FirstSeen:   row.FirstSeen.Time.Format(time.RFC3339),
+			LastSeen:    row.LastSeen.Time.Format(time.RFC3339),
+		}
+	}
+
+	return writeJSON(w, res)
+}
+
+type deletePrefixRequest struct {
+	Language string `json:"language"`
+	Prefix   string `json:"prefix"`
+}
+
+type deletePrefixResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+// deleteEntriesByPrefix deletes all entries of a language that start with a
+// prefix, f.ex. the code names of a retired project. The entries are only
+// marked as deleted, so they can be restored one by one.
+func (a *Application) deleteEntriesByPrefix(
+	w http.ResponseWriter, r *http.Request,
+) (outErr error) {
+	ctx := r.Context()
+
+	auth, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckAdmin)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	var req deletePrefixRequest
+
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	if req.Language == "" {
+		return twirp.RequiredArgumentError("language")
+	}
+
+	if !a.isSupportedLanguage(req.Language) {
+		return a.unsupportedLanguageError("language", req.Language)
+	}
+
+	// An empty prefix would delete the whole dictionary.
+	if strings.TrimSpace(req.Prefix) == "" {
+		return twirp.RequiredArgumentError("prefix")
+	}
+
+	language := NormalizeLanguage(req.Language)
+
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("start transaction: %w", err)
+	}
+
+	defer pg.Rollback(tx, &outErr)
+
+	q := a.queries(tx)
+
+	texts, err := deleteEntriesWithHistory(ctx, q,
+		postgres.DeleteEntriesByPrefixParams{
+			UpdatedBy: auth.Claims.Subject,
+			Language:  language,
+			Pattern:   prefixPattern(NormalizeEntryText(req.Prefix)),
+		})
+	if err != nil {
+		return twirp.InternalErrorf("write to database: %w", err)
+	}
+
+	err = notifyEntriesUpdated(ctx, q, language, texts, true)
+	if err != nil {
+		return twirp.InternalErrorf("send notification: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("commit changes: %w", err)
+	}
+
+	return writeJSON(w, deletePrefixResponse{
+		Deleted: len(texts),
+	})
+}
+
+type entryChange struct {
+	Action    EntryAction `json:"action"`
+	OldStatus string      `json:"old_status,omitempty"`
+	NewStatus string      `json:"new_status,omitempty"`
+	UpdatedBy string      `json:"updated_by"`
+	Created   string      `json:"created"`
+}
+
+type entryHistoryResponse struct {
+	Changes []entryChange `json:"changes"`
+}
+
+// entryHistory returns the changes that have been made to an entry, oldest
+// first.
+func (a *Application) entryHistory(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckWrite)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	query := r.URL.Query()
+
+	language := NormalizeLanguage(query.Get("language"))
+	if language == "" {
+		return twirp.RequiredArgumentError("language")
+	}
+
+	text := NormalizeEntryText(query.Get("text"))
+	if text == "" {
+		return twirp.RequiredArgumentError("text")
+	}
+
+	rows, err := a.q.GetEntryHistory(ctx, postgres.GetEntryHistoryParams{
+		Language: language,
+		Entry:    text,
+	})
+	if err != nil {
+		return twirp.InternalErrorf("read from database: %w", err)
+	}
+
+	res := entryHistoryResponse{
+		Changes: make([]entryChange, len(rows)),
+	}
+
+	for i, row := range rows {
+		res.Changes[i] = entryChange{
+			Action:    EntryAction(row.Action),
+			OldStatus: row.OldStatus.String,
+			NewStatus: row.NewStatus.String,
+			UpdatedBy: row.UpdatedBy,
+			Created:   row.Created.Time.Format(time.RFC3339),
+		}
+	}
+
+	return writeJSON(w, res)
+}
+
+const (
+	// minSimilarity is the lowest similarity that can be searched for,
+	// it's the default similarity threshold of pg_trgm, below which the
+	// trigram index doesn't find any entries.
+	minSimilarity = 0.3
+	// defaultSimilarity is the similarity threshold used when the client
+	// doesn't ask for a specific one.
+	defaultSimilarity = 0.4
+	// similarEntriesLimit is the maximum number of similar entries that
+	// are returned.
+	similarEntriesLimit = 10
+)
+
+type similarEntry struct {
+	entryRecord
+
+	Similarity float32 `json:"similarity"`
+}
+
+type similarEntriesResponse struct {
+	Entries []similarEntry `json:"entries"`
+}
+
+// similarEntries finds existing entries that are spelled like a text, so that
+// curators can spot a near duplicate before adding a new entry. The most
+// similar entries come first.
+func (a *Application) similarEntries(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckWrite)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	query := r.URL.Query()
+
+	language := NormalizeLanguage(query.Get("language"))
+	if language == "" {
+		return twirp.RequiredArgumentError("language")
+	}
+
+	text := NormalizeEntryText(query.Get("text"))
+	if text == "" {
+		return twirp.RequiredArgumentError("text")
+	}
+
+	threshold := defaultSimilarity
+
+	if v := query.Get("threshold"); v != "" {
+		t, err := strconv.ParseFloat(v, 32)
+		if err != nil || t < minSimilarity || t > 1 {
+			return twirp.InvalidArgumentError("threshold", fmt.Sprintf(
+				"must be a number between %v and 1", minSimilarity))
+		}
+
+		threshold = t
+	}
+
+	rows, err := a.q.FindSimilarEntries(ctx, postgres.FindSimilarEntriesParams{
+		Text:      text,
+		Language:  language,
+		Threshold: float32(threshold),
+		Limit:     similarEntriesLimit,
+	})
+	if err != nil {
+		return twirp.InternalErrorf("read from database: %w", err)
+	}
+
+	res := similarEntriesResponse{
+		Entries: make([]similarEntry, len(rows)),
+	}
+
+	for i, row := range rows {
+		res.Entries[i] = similarEntry{
+			entryRecord: entryRecordFromRow(postgres.Entry{
+				Language:       row.Language,
+				Entry:          row.Entry,
+				Status:         row.Status,
+				Description:    row.Description,
+				CommonMistakes: row.CommonMistakes,
+				UpdatedAt:      row.UpdatedAt,
+				UpdatedBy:      row.UpdatedBy,
+				DeletedAt:      row.DeletedAt,
+				MatchMode:      row.MatchMode,
+				Level:          row.Level,
+				Forms:          row.Forms,
+				Enforced:       row.Enforced,
+				AffixModel:     row.AffixModel,
+			}),
+			Similarity: row.Similarity,
+		}
+	}
+
+	return writeJSON(w, res)
+}
+
+// getEntry returns an entry together with its current version.
+func (a *Application) getEntry(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	_, err := elephantine.RequireAnyScope(ctx, ScopeSpellcheckWrite)
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	query := r.URL.Query()
+
+	language := NormalizeLanguage(query.Get("language"))
+	if language == "" {
+		return twirp.RequiredArgumentError("language")
+	}
+
+	text := NormalizeEntryText(query.Get("text"))
+	if text == "" {
+		return twirp.RequiredArgumentError("text")
+	}
+
+	row, err := a.q.GetEntry(ctx, postgres.GetEntryParams{
+		Language: language,
+		Entry:    text,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return twirp.NotFoundError("no such entry")
+	}
+
+	if err != nil {
+		return twirp.InternalErrorf("read from database: %w", err)
+	}
+
+	return writeJSON(w, entryRecordFromRow(row))
+}
+
+// updateEntry writes an entry if it hasn't been changed since the client
+// read it. The version must be the version of the stored entry, or empty if
+// the entry is new. Responds with the entry and its new version.
+func (a *Application) updateEntry(
+	w http.ResponseWriter, r *http.Request,
+) (outErr error) {
+	ctx := r.Context()
+
+	var req entryRecord
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	err = a.prepareEntryRecord("entry", &req)
+	if err != nil {
+		return err
+	}
+
+	auth, err := requireWriteAccess(ctx, req.Language)
+	if err != nil {
+		return err
+	}
+
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("start transaction: %w", err)
+	}
+
+	defer pg.Rollback(tx, &outErr)
+
+	q := a.queries(tx)
+
+	current, err := currentEntry(ctx, q, req.Language, req.Text)
+	if err != nil {
+		return twirp.InternalErrorf("read from database: %w", err)
+	}
+
+	var version string
+
+	if entryStatus(current).Valid {
+		version = entryVersion(*current)
+	}
+
+	if req.Version != version {
+		return twirp.FailedPrecondition.Error(
+			"the entry has been changed by someone else, reload it")
+	}
+
+	err = setEntryWithHistory(ctx, q, req.SetEntryParams(auth.Claims.Subject))
+	if err != nil {
+		return twirp.InternalErrorf("write to database: %w", err)
+	}
+
+	row, err := q.GetEntry(ctx, postgres.GetEntryParams{
+		Language: req.Language,
+		Entry:    req.Text,
+	})
+	if err != nil {
+		return twirp.InternalErrorf("read updated entry: %w", err)
+	}
+
+	err = notifyEntryUpdated(ctx, q, EntryUpdateNotification{
+		Language: req.Language,
+		Text:     req.Text,
+	})
+	if err != nil {
+		return twirp.InternalErrorf("send notification: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("commit changes: %w", err)
+	}
+
+	return writeJSON(w, entryRecordFromRow(row))
+}
+
+type mergeEntryRequest struct {
+	Language       string   `json:"language"`
+	Text           string   `json:"text"`
+	Description    string   `json:"description"`
+	Level          string   `json:"level"`
+	CommonMistakes []string `json:"common_mistakes"`
+	Forms          []string `json:"forms"`
+}
+
+// mergeEntry adds common mistakes and forms to an existing entry, and
+// replaces the description and level if they're given, without the client
+// having to read and write back the whole entry. Responds with the merged
+// entry.
+func (a *Application) mergeEntry(
+	w http.ResponseWriter, r *http.Request,
+) (outErr error) {
+	ctx := r.Context()
+
+	var req mergeEntryRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	if req.Language == "" {
+		return twirp.RequiredArgumentError("language")
+	}
+
+	if req.Text == "" {
+		return twirp.RequiredArgumentError("text")
+	}
+
+	if req.Description == "" && req.Level == "" &&
+		len(req.CommonMistakes) == 0 && len(req.Forms) == 0 {
+		return twirp.InvalidArgumentError("common_mistakes",
+			"a description, level, common mistakes, or forms must be given")
+	}
+
+	req.Language = NormalizeLanguage(req.Language)
+	req.Text = NormalizeEntryText(req.Text)
+
+	auth, err := requireWriteAccess(ctx, req.Language)
+	if err != nil {
+		return err
+	}
+
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("start transaction: %w", err)
+	}
+
+	defer pg.Rollback(tx, &outErr)
+
+	q := a.queries(tx)
+
+	// The entry is locked until we commit, so concurrent merges are
+	// applied one after the other.
+	current, err := currentEntry(ctx, q, req.Language, req.Text)
+	if err != nil {
+		return twirp.InternalErrorf("read from database: %w", err)
+	}
+
+	if !entryStatus(current).Valid {
+		return twirp.NotFoundError("no such entry")
+	}
+
+	merged := entryRecordFromRow(*current)
+
+	if req.Description != "" {
+		merged.Description = req.Description
+	}
+
+	if req.Level != "" {
+		merged.Level = req.Level
+	}
+
+	merged.CommonMistakes = mergeTexts(
+		current.CommonMistakes, req.CommonMistakes)
+	merged.Forms = mergeTexts(current.Forms, req.Forms)
+
+	err = a.prepareEntryRecord("entry", &merged)
+	if err != nil {
+		return err
+	}
+
+	err = setEntryWithHistory(ctx, q, merged.SetEntryParams(auth.Claims.Subject))
+	if err != nil {
+		return twirp.InternalErrorf("write to database: %w", err)
+	}
+
+	row, err := q.GetEntry(ctx, postgres.GetEntryParams{
+		Language: req.Language,
+		Entry:    req.Text,
+	})
+	if err != nil {
+		return twirp.InternalErrorf("read updated entry: %w", err)
+	}
+
+	err = notifyEntryUpdated(ctx, q, EntryUpdateNotification{
+		Language: req.Language,
+		Text:     req.Text,
+	})
+	if err != nil {
+		return twirp.InternalErrorf("send notification: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("commit changes: %w", err)
+	}
+
+	return writeJSON(w, entryRecordFromRow(row))
+}
+
+// mergeTexts appends the texts that the list doesn't have yet, after
+// normalizing them. Empty texts are skipped.
+func mergeTexts(list []string, texts []string) []string {
+	merged := slices.Clone(list)
+
+	for _, text := range texts {
+		text = NormalizeEntryText(text)
+
+		if text == "" || slices.Contains(merged, text) {
+			continue
+		}
+
+		merged = append(merged, text)
+	}
+
+	return merged
+}
+
+// proposeEntry adds an entry that is pending review. Pending entries aren't
+// used when checking texts until they have been approved.
+func (a *Application) proposeEntry(
+	w http.ResponseWriter, r *http.Request,
+) (outErr error) {
+	ctx := r.Context()
+
+	var req entryRecord
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	req.Status = StatusPending
+
+	err = a.prepareEntryRecord("entry", &req)
+	if err != nil {
+		return err
+	}
+
+	auth, err := elephantine.RequireAnyScope(ctx,
+		ScopeSpellcheckPropose, ScopeSpellcheckWrite,
+		LanguageWriteScope(req.Language))
+	if err != nil {
+		return err //nolint: wrapcheck
+	}
+
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("start transaction: %w", err)
+	}
+
+	defer pg.Rollback(tx, &outErr)
+
+	q := a.queries(tx)
+
+	current, err := currentEntry(ctx, q, req.Language, req.Text)
+	if err != nil {
+		return twirp.InternalErrorf("read from database: %w", err)
+	}
+
+	// A proposal must never replace an entry that is in use.
+	if entryStatus(current).Valid {
+		return twirp.AlreadyExists.Error("the entry already exists")
+	}
+
+	err = setEntryWithHistory(ctx, q, req.SetEntryParams(auth.Claims.Subject))
+	if err != nil {
+		return twirp.InternalErrorf("write to database: %w", err)
+	}
+
+	// No notification is sent, pending entries aren't loaded.
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("commit changes: %w", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+type reviewEntryRequest struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+// approveEntry approves an entry, which makes it live.
+func (a *Application) approveEntry(w http.ResponseWriter, r *http.Request) error {
+	return a.reviewEntry(w, r, StatusApproved)
+}
+
+// rejectEntry rejects an entry, which takes it out of use.
+func (a *Application) rejectEntry(w http.ResponseWriter, r *http.Request) error {
+	return a.reviewEntry(w, r, StatusRejected)
+}
+
+// reviewEntry sets the status of an existing entry and notifies the other
+// instances so that the entry is loaded or unloaded.
+func (a *Application) reviewEntry(
+	w http.ResponseWriter, r *http.Request, status string,
+) (outErr error) {
+	ctx := r.Context()
+
+	var req reviewEntryRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return twirp.Malformed.Errorf("invalid request body: %v", err)
+	}
+
+	if req.Language == "" {
+		return twirp.RequiredArgumentError("language")
+	}
+
+	if req.Text == "" {
+		return twirp.RequiredArgumentError("text")
+	}
+
+	req.Language = NormalizeLanguage(req.Language)
+	req.Text = NormalizeEntryText(req.Text)
+
+	auth, err := requireWriteAccess(ctx, req.Language)
+	if err != nil {
+		return err
+	}
+
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("start transaction: %w", err)
+	}
+
+	defer pg.Rollback(tx, &outErr)
+
+	q := a.queries(tx)
+
+	current, err := currentEntry(ctx, q, req.Language, req.Text)
+	if err != nil {
+		return twirp.InternalErrorf("read from database: %w", err)
+	}
+
+	if current == nil || current.DeletedAt.Valid {
+		return twirp.NotFoundError("no such entry")
+	}
+
+	err = setEntryWithHistory(ctx, q, postgres.SetEntryParams{
+		Language:       current.Language,
+		Entry:          current.Entry,
+		Status:         status,
+		Description:    current.Description,
+		CommonMistakes: current.CommonMistakes,
+		UpdatedBy:      auth.Claims.Subject,
+	})
+	if err != nil {
+		return twirp.InternalErrorf("write to database: %w", err)
+	}
+
+	err = notifyEntryUpdated(ctx, q, EntryUpdateNotification{
+		Language: req.Language,
+		Text:     req.Text,
+	})
+	if err != nil {
+		return twirp.InternalErrorf("send notification: %w", err)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return twirp.InternalErrorf("commit changes: %w", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		return fmt.Errorf("write response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ttab/elephant-spell/postgres"
+	"github.com/ttab/elephantine"
+)
+
+const (
+	// gapFlushInterval is how often recorded spelling gaps are written to
+	// the database.
+	gapFlushInterval = 30 * time.Second
+	// maxPendingGaps is the number of distinct words that can be waiting
+	// to be written, new words are dropped until the next flush.
+	maxPendingGaps = 10000
+	// gapFlushTimeout is how long we wait for the last flush when
+	// shutting down.
+	gapFlushTimeout = 5 * time.Second
+)
+
+type gapKey struct {
+	Language string
+	Word     string
+}
+
+// gapRecorder counts the misspelled words that hunspell had no suggestions
+// for. The counts are kept in memory and written to the database in batches,
+// so that a check never waits for the database.
+type gapRecorder struct {
+	m      sync.Mutex
+	counts map[gapKey]int64
+}
+
+func newGapRecorder() *gapRecorder {
+	return &gapRecorder{
+		counts: make(map[gapKey]int64),
+	}
+}
+
+// Record counts an occurrence of a word without suggestions. A nil recorder
+// is disabled.
+func (r *gapRecorder) Record(language string, word string) {
+	if r == nil {
+		return
+	}
+
+	key := gapKey{Language: language, Word: word}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	_, ok := r.counts[key]
+	if !ok && len(r.counts) >= maxPendingGaps {
+		return
+	}
+
+	r.counts[key]++
+}
+
+// take returns the counts since the last call.
+func (r *gapRecorder) take() map[gapKey]int64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	counts := r.counts
+	r.counts = make(map[gapKey]int64)
+
+	return counts
+}
+
+// runGapRecorder writes the recorded spelling gaps to the database until the
+// context is cancelled.
+func (a *Application) runGapRecorder(ctx context.Context) error {
+	ticker := time.NewTicker(gapFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(
+				context.WithoutCancel(ctx), gapFlushTimeout)
+
+			a.flushGaps(flushCtx)
+			cancel()
+
+			return ctx.Err()
+		case <-ticker.C:
+			a.flushGaps(ctx)
+		}
+	}
+}
+
+// flushGaps writes the spelling gaps recorded since the last flush. Failures
+// are logged, the gaps are only a guide for curators.
+func (a *Application) flushGaps(ctx context.Context) {
+	perLanguage := make(map[string]*postgres.AddSpellingGapsParams)
+
+	for key, count := range a.gaps.take() {
+		params, ok := perLanguage[key.Language]
+		if !ok {
+			params = &postgres.AddSpellingGapsParams{
+				Language: key.Language,
+			}
+			perLanguage[key.Language] = params
+		}
+
+		params.Words = append(params.Words, key.Word)
+		params.Occurrences = append(params.Occurrences, count)
+	}
+
+	for language, params := range perLanguage {
+		err := a.q.AddSpellingGaps(ctx, *params)
+		if err != nil {
+			a.logger.ErrorContext(ctx, "failed to write spelling gaps",
+				elephantine.LogKeyError, err,
+				"language", language)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"container/list"
+	"sync"
+)
+
+// SuggestionCache is a bounded LRU cache of hunspell suggestions keyed by
+// language, glossary, and misspelled word. A nil cache is disabled, lookups
+// miss and nothing is stored.
+//
+// A new custom entry can become a suggestion for any misspelling, so the
+// cache of a language is purged when its entries change rather than trying
+// to work out which words were affected.
+type SuggestionCache struct {
+	size int
+
+	m     sync.Mutex
+	order *list.List
+	items map[suggestionKey]*list.Element
+	// generation is bumped by every purge so that suggestions that were
+	// computed before the purge aren't stored after it.
+	generation uint64
+}
+
+type suggestionKey struct {
+	Language string
+	Glossary string
+	Word     string
+}
+
+type cachedSuggestions struct {
+	Key         suggestionKey
+	Suggestions []string
+}
+
+// NewSuggestionCache creates a cache that holds the suggestions for at most
+// size words. Returns nil, a disabled cache, if size isn't positive.
+func NewSuggestionCache(size int) *SuggestionCache {
+	if size <= 0 {
+		return nil
+	}
+
+	return &SuggestionCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[suggestionKey]*list.Element, size),
+	}
+}
+
+// Get returns the cached suggestions for a word. The returned generation
+// must be passed to Add when storing suggestions after a miss. The returned
+// slice is shared and must not be modified.
+func (c *SuggestionCache) Get(
+	language string, glossary string, word string,
+) (_ []string, generation uint64, ok bool) {
+	if c == nil {
+		return nil, 0, false
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	el, ok := c.items[suggestionKey{
+		Language: language,
+		Glossary: glossary,
+		Word:     word,
+	}]
+	if !ok {
+		return nil, c.generation, false
+	}
+
+	c.order.MoveToFront(el)
+
+	return el.Value.(*cachedSuggestions).Suggestions, c.generation, true //nolint: forcetypeassert
+}
+
+// Add stores the suggestions for a word, evicting the least recently used
+// word if the cache is full. Nothing is stored if the cache has been purged
+// since the generation was returned by Get.
+func (c *SuggestionCache) Add(
+	language string, glossary string, word string,
+	generation uint64, suggestions []string,
+) {
+	if c == nil {
+		return
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	key := suggestionKey{
+		Language: language,
+		Glossary: glossary,
+		Word:     word,
+	}
+
+	el, ok := c.items[key]
+	if ok {
+		el.Value.(*cachedSuggestions).Suggestions = suggestions //nolint: forcetypeassert
+
+		c.order.MoveToFront(el)
+
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&cachedSuggestions{
+		Key:         key,
+		Suggestions: suggestions,
+	})
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cachedSuggestions).Key) //nolint: forcetypeassert
+	}
+}
+
+// PurgeLanguage removes the cached suggestions of a language, including the
+// suggestions of its glossaries.
+func (c *SuggestionCache) PurgeLanguage(language string) {
+	if c == nil {
+		return
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.generation++
+
+	for el := c.order.Front(); el != nil; {
+		next := el.Next()
+
+		cached := el.Value.(*cachedSuggestions) //nolint: forcetypeassert
+		if cached.Key.Language == language {
+			c.order.Remove(el)
+			delete(c.items, cached.Key)
+		}
+
+		el = next
+	}
+}
+
+// Purge removes all cached suggestions.
+func (c *SuggestionCache) Purge() {
+	if c == nil {
+		return
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.generation++
+	c.order.Init()
+	clear(c.items)
+}
+
+// Len returns the number of words in the cache.
+func (c *SuggestionCache) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.order.Len()
+}
@@ -0,0 +1,48 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/ttab/elephant-spell/internal"
+	"github.com/ttab/elephantine/test"
+)
+
+func TestSuggestionCache(t *testing.T) {
+	cache := internal.NewSuggestionCache(2)
+
+	_, gen, ok := cache.Get("sv-se", "", "rätstavad")
+	test.Equal(t, false, ok, "miss on an empty cache")
+
+	cache.Add("sv-se", "", "rätstavad", gen, []string{"rättstavad"})
+	cache.Add("en-us", "", "teh", gen, []string{"the"})
+
+	got, _, ok := cache.Get("sv-se", "", "rätstavad")
+	test.Equal(t, true, ok, "hit after add")
+	test.EqualDiff(t, []string{"rättstavad"}, got, "cached suggestions")
+
+	// "teh" is now the least recently used word.
+	cache.Add("sv-se", "", "emmellan", gen, []string{"emellan"})
+
+	_, _, ok = cache.Get("en-us", "", "teh")
+	test.Equal(t, false, ok, "evict the least recently used word")
+
+	_, gen, _ = cache.Get("sv-se", "", "xyzzy")
+
+	cache.PurgeLanguage("sv-se")
+
+	test.Equal(t, 0, cache.Len(), "purge the language")
+
+	cache.Add("sv-se", "", "xyzzy", gen, nil)
+
+	_, _, ok = cache.Get("sv-se", "", "xyzzy")
+	test.Equal(t, false, ok, "don't store suggestions from before a purge")
+}
+
+func TestDisabledSuggestionCache(t *testing.T) {
+	cache := internal.NewSuggestionCache(0)
+
+	cache.Add("sv-se", "", "rätstavad", 0, []string{"rättstavad"})
+
+	_, _, ok := cache.Get("sv-se", "", "rätstavad")
+	test.Equal(t, false, ok, "a disabled cache always misses")
+}
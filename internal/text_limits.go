@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/twitchtv/twirp"
+)
+
+// TextLimits restricts the size of the texts in a check request, so that a
+// single request can't pin a CPU. A zero limit is unlimited.
+type TextLimits struct {
+	// MaxBytes is the maximum total size of the texts.
+	MaxBytes int
+	// MaxItems is the maximum number of texts.
+	MaxItems int
+}
+
+// Validate returns an invalid argument error if the texts exceed the limits.
+func (l TextLimits) Validate(texts []string) error {
+	if l.MaxItems > 0 && len(texts) > l.MaxItems {
+		return twirp.InvalidArgumentError("text", fmt.Sprintf(
+			"got %d texts, the limit is %d texts",
+			len(texts), l.MaxItems))
+	}
+
+	if l.MaxBytes <= 0 {
+		return nil
+	}
+
+	var size int
+
+	for _, t := range texts {
+		size += len(t)
+	}
+
+	if size > l.MaxBytes {
+		return twirp.InvalidArgumentError("text", fmt.Sprintf(
+			"got %d bytes of text, the limit is %d bytes",
+			size, l.MaxBytes))
+	}
+
+	return nil
+}
+
+type textChunk struct {
+	Text string `json:"text"`
+}
+
+// Chunks reads a stream of newline delimited JSON text chunks, f.ex.
+// {"text": "Första stycket."}, and yields the text of every chunk. Every chunk
+// is validated against the limits before it's yielded, so an oversized chunk
+// ends the stream with an error instead of being checked. Nothing more is
+// yielded after an error.
+func (l TextLimits) Chunks(r io.Reader) func(yield func(string, error) bool) {
+	dec := json.NewDecoder(r)
+
+	return func(yield func(string, error) bool) {
+		for {
+			var chunk textChunk
+
+			err := dec.Decode(&chunk)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			if err != nil {
+				yield("", twirp.Malformed.Errorf(
+					"invalid text chunk: %v", err))
+
+				return
+			}
+
+			err = l.Validate([]string{chunk.Text})
+			if err != nil {
+				yield("", err)
+
+				return
+			}
+
+			if !yield(chunk.Text, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package internal_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ttab/elephant-spell/internal"
+	"github.com/ttab/elephantine/test"
+	"github.com/twitchtv/twirp"
+)
+
+func TestTextLimits(t *testing.T) {
+	limits := internal.TextLimits{
+		MaxBytes: 100,
+		MaxItems: 3,
+	}
+
+	test.Must(t, limits.Validate([]string{
+		strings.Repeat("a", 50),
+		strings.Repeat("b", 50),
+	}), "accept texts within the limits")
+
+	assertInvalidText(t, limits.Validate([]string{
+		strings.Repeat("a", 50),
+		strings.Repeat("b", 51),
+	}), "reject texts over the byte limit")
+
+	assertInvalidText(t, limits.Validate([]string{"a", "b", "c", "d"}),
+		"reject too many texts")
+
+	test.Must(t, internal.TextLimits{}.Validate([]string{
+		strings.Repeat("a", 10_000),
+	}), "zero limits are unlimited")
+}
+
+func TestTextLimitsChunks(t *testing.T) {
+	limits := internal.TextLimits{MaxBytes: 10}
+
+	stream := strings.Join([]string{
+		`{"text": "Hej"}`,
+		`{"text": "` + strings.Repeat("a", 11) + `"}`,
+		`{"text": "Hej då"}`,
+	}, "\n")
+
+	var (
+		checked []string
+		errs    []error
+	)
+
+	for text, err := range limits.Chunks(strings.NewReader(stream)) {
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		checked = append(checked, text)
+	}
+
+	test.EqualDiff(t, []string{"Hej"}, checked,
+		"only hand out the chunk within the limit for checking")
+	test.Equal(t, 1, len(errs), "end the stream at the oversized chunk")
+
+	assertInvalidText(t, errs[0], "reject the oversized chunk")
+}
+
+func assertInvalidText(t *testing.T, err error, msg string) {
+	t.Helper()
+
+	var tErr twirp.Error
+
+	if !errors.As(err, &tErr) {
+		t.Fatalf("%s: expected a twirp error, got %v", msg, err)
+	}
+
+	test.Equal(t, twirp.InvalidArgument, tErr.Code(), "%s: error code", msg)
+	test.Equal(t, "text", tErr.Meta("argument"), "%s: argument", msg)
+}
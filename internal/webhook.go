@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ttab/elephantine"
+)
+
+const (
+	// webhookTimeout is the timeout for a single webhook request.
+	webhookTimeout = 5 * time.Second
+	// webhookAttempts is the number of times we try to deliver a
+	// notification before giving up.
+	webhookAttempts = 3
+	// webhookQueueSize is the number of notifications that can be waiting
+	// for delivery before new ones are dropped.
+	webhookQueueSize = 1000
+)
+
+// queueWebhook queues an entry update notification for delivery to the
+// webhook. The notification is dropped if the queue is full, so that a slow
+// endpoint never stalls the entry updater.
+func (a *Application) queueWebhook(n EntryUpdateNotification) {
+	if a.webhooks == nil {
+		return
+	}
+
+	select {
+	case a.webhooks <- n:
+	default:
+		a.logger.Warn("webhook queue is full, dropping entry update",
+			"language", n.Language,
+			"text", n.Text)
+	}
+}
+
+// runWebhookSender posts queued entry update notifications to the webhook
+// until the context is cancelled.
+func (a *Application) runWebhookSender(ctx context.Context) error {
+	for {
+		var n EntryUpdateNotification
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case n = <-a.webhooks:
+		}
+
+		err := a.deliverWebhook(ctx, n)
+		if err != nil {
+			a.logger.ErrorContext(ctx, "failed to deliver entry update webhook",
+				elephantine.LogKeyError, err,
+				"language", n.Language,
+				"text", n.Text)
+		}
+	}
+}
+
+// deliverWebhook posts a notification to the webhook, retrying with a
+// backoff if the request fails.
+func (a *Application) deliverWebhook(
+	ctx context.Context, n EntryUpdateNotification,
+) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("marshal notification: %w", err)
+	}
+
+	var lastErr error
+
+	for attempt := range webhookAttempts {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+
+		lastErr = a.postWebhook(ctx, body)
+		if lastErr == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("gave up after %d attempts: %w",
+		webhookAttempts, lastErr)
+}
+
+func (a *Application) postWebhook(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		a.p.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", res.Status)
+	}
+
+	return nil
+}
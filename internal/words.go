@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/segment"
+)
+
+// Words iterates over the words in a text using the default segmentation.
+func Words(text []byte) func(yield func(word string) bool) {
+	return Segmentation{}.Words(text)
+}
+
+// Words iterates over the words in a text. Leading and trailing punctuation is
+// trimmed from the words so that a correctly spelled word isn't flagged just
+// because the segmenter let punctuation cling to it.
+func (s Segmentation) Words(text []byte) func(yield func(word string) bool) {
+	return func(yield func(word string) bool) {
+		for t := range s.Tokens(text) {
+			if t.Type != segment.Letter {
+				continue
+			}
+
+			word := strings.TrimFunc(t.Text, isWordEdgePunct)
+			if word == "" {
+				continue
+			}
+
+			if !yield(word) {
+				return
+			}
+		}
+	}
+}
+
+func isWordEdgePunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// HasDigit returns true if the word contains a digit. Words like "3D", "H2O"
+// and "COVID-19" are abbreviations or designations rather than dictionary
+// words, so they aren't checked by default.
+func HasDigit(word string) bool {
+	return strings.IndexFunc(word, unicode.IsDigit) != -1
+}
@@ -0,0 +1,43 @@
+package internal_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ttab/elephant-spell/internal"
+	"github.com/ttab/elephantine/test"
+)
+
+func TestWords(t *testing.T) {
+	cases := map[string][]string{
+		"Ordet, och sedan ordet.": {"Ordet", "och", "sedan", "ordet"},
+		"(ordet)":                 {"ordet"},
+		"\"ordet\"":               {"ordet"},
+		"'ordet'":                 {"ordet"},
+		"«ordet»":                 {"ordet"},
+		"”ordet”, sa hon":         {"ordet", "sa", "hon"},
+		"don't":                   {"don't"},
+	}
+
+	for text, want := range cases {
+		got := slices.Collect(internal.Words([]byte(text)))
+
+		test.EqualDiff(t, want, got, "words in %q", text)
+	}
+}
+
+func TestHasDigit(t *testing.T) {
+	cases := map[string]bool{
+		"COVID-19": true,
+		"3D":       true,
+		"2024":     true,
+		"H2O":      true,
+		"COVID":    false,
+		"e-post":   false,
+	}
+
+	for word, want := range cases {
+		test.Equal(t, want, internal.HasDigit(word),
+			"digits in %q", word)
+	}
+}
@@ -4,7 +4,9 @@
 
 package postgres
 
-import ()
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
 
 type Entry struct {
 	Language       string
@@ -12,8 +14,35 @@ type Entry struct {
 	Status         string
 	Description    string
 	CommonMistakes []string
+	UpdatedAt      pgtype.Timestamptz
+	UpdatedBy      string
+	DeletedAt      pgtype.Timestamptz
+	MatchMode      string
+	Level          string
+	Forms          []string
+	Enforced       bool
+	AffixModel     string
+}
+
+type EntryHistory struct {
+	ID        int64
+	Language  string
+	Entry     string
+	Action    string
+	OldStatus pgtype.Text
+	NewStatus pgtype.Text
+	UpdatedBy string
+	Created   pgtype.Timestamptz
 }
 
 type SchemaVersion struct {
 	Version int32
 }
+
+type SpellingGap struct {
+	Language    string
+	Word        string
+	Occurrences int64
+	FirstSeen   pgtype.Timestamptz
+	LastSeen    pgtype.Timestamptz
+}
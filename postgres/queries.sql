@@ -1,36 +1,182 @@
 -- name: SetEntry :exec
 INSERT INTO entry(
-       language, entry, status, description, common_mistakes
+       language, entry, status, description, common_mistakes,
+       updated_at, updated_by, match_mode, level, forms,
+       enforced, affix_model
 ) VALUES (
-       @language, @entry, @status, @description, @common_mistakes
+       @language, @entry, @status, @description, @common_mistakes,
+       now(), @updated_by,
+       COALESCE(sqlc.narg('match_mode')::text, 'sequence'),
+       COALESCE(sqlc.narg('level')::text, 'auto'),
+       COALESCE(sqlc.narg('forms')::text[], '{}'),
+       COALESCE(sqlc.narg('enforced')::bool, false),
+       COALESCE(sqlc.narg('affix_model')::text, '')
 ) ON CONFLICT(language, entry) DO
   UPDATE SET
        status = @status,
        description = @description,
-       common_mistakes = @common_mistakes;
+       common_mistakes = @common_mistakes,
+       updated_at = now(),
+       updated_by = @updated_by,
+       deleted_at = NULL,
+       match_mode = COALESCE(sqlc.narg('match_mode')::text, entry.match_mode),
+       level = COALESCE(sqlc.narg('level')::text, entry.level),
+       forms = COALESCE(sqlc.narg('forms')::text[], entry.forms),
+       enforced = COALESCE(sqlc.narg('enforced')::bool, entry.enforced),
+       affix_model = COALESCE(sqlc.narg('affix_model')::text, entry.affix_model);
 
 -- name: GetEntry :one
-SELECT language, entry, status, description, common_mistakes
+SELECT language, entry, status, description, common_mistakes,
+       updated_at, updated_by, deleted_at, match_mode, level, forms,
+       enforced, affix_model
 FROM entry
-WHERE language = @language AND entry = @entry;
+WHERE language = @language AND entry = @entry AND deleted_at IS NULL;
+
+-- name: GetEntryForUpdate :one
+SELECT language, entry, status, description, common_mistakes,
+       updated_at, updated_by, deleted_at, match_mode, level, forms,
+       enforced, affix_model
+FROM entry
+WHERE language = @language AND entry = @entry
+FOR UPDATE;
 
 -- name: DeleteEntry :exec
-DELETE FROM entry
-WHERE language = @language AND entry = @entry;
+UPDATE entry
+SET deleted_at = now(), updated_at = now(), updated_by = @updated_by
+WHERE language = @language AND entry = @entry AND deleted_at IS NULL;
+
+-- name: RestoreEntry :execrows
+UPDATE entry
+SET deleted_at = NULL, updated_at = now(), updated_by = @updated_by
+WHERE language = @language AND entry = @entry AND deleted_at IS NOT NULL;
 
 -- name: ListEntries :many
-SELECT language, entry, status, description, common_mistakes
+SELECT language, entry, status, description, common_mistakes,
+       updated_at, updated_by, deleted_at, match_mode, level, forms,
+       enforced, affix_model
 FROM entry
 WHERE
         (sqlc.narg('language')::text IS NULL OR language = @language)
         AND (sqlc.narg('pattern')::text IS NULL OR entry LIKE @pattern)
         AND (sqlc.narg('status')::text IS NULL OR status = @status)
+        AND deleted_at IS NULL
+ORDER BY language, entry
 LIMIT sqlc.arg('limit')::bigint OFFSET sqlc.arg('offset')::bigint;
 
 -- name: ListDictionaries :many
 SELECT language, COUNT(*) AS entries
 FROM entry
+WHERE deleted_at IS NULL
 GROUP BY language;
 
 -- name: Notify :exec
 SELECT pg_notify(@channel::text, @message::text);
+
+-- name: IterateEntries :many
+SELECT language, entry, status, description, common_mistakes,
+       updated_at, updated_by, deleted_at, match_mode, level, forms,
+       enforced, affix_model
+FROM entry
+WHERE
+        (sqlc.narg('language')::text IS NULL OR language = @language)
+        AND (sqlc.narg('pattern')::text IS NULL OR entry LIKE @pattern)
+        AND (sqlc.narg('status')::text IS NULL OR status = @status)
+        AND (sqlc.narg('mistake')::text IS NULL OR EXISTS (
+            SELECT FROM unnest(common_mistakes) AS m WHERE m LIKE @mistake))
+        AND (@include_deleted::bool OR deleted_at IS NULL)
+        AND (language, entry) > (@after_language::text, @after_entry::text)
+ORDER BY language, entry
+LIMIT sqlc.arg('limit')::bigint;
+
+-- name: GetEntries :many
+SELECT e.language, e.entry, e.status, e.description, e.common_mistakes,
+       e.updated_at, e.updated_by, e.deleted_at, e.match_mode, e.level, e.forms,
+       e.enforced, e.affix_model
+FROM entry AS e
+     INNER JOIN unnest(@languages::text[], @entries::text[]) AS k(language, entry)
+           ON e.language = k.language AND e.entry = k.entry
+WHERE @include_deleted::bool OR e.deleted_at IS NULL;
+
+-- name: CountEntries :one
+SELECT COUNT(*)
+FROM entry
+WHERE
+        (sqlc.narg('language')::text IS NULL OR language = @language)
+        AND (sqlc.narg('pattern')::text IS NULL OR entry LIKE @pattern)
+        AND (sqlc.narg('status')::text IS NULL OR status = @status)
+        AND (sqlc.narg('mistake')::text IS NULL OR EXISTS (
+            SELECT FROM unnest(common_mistakes) AS m WHERE m LIKE @mistake))
+        AND (@include_deleted::bool OR deleted_at IS NULL);
+
+-- name: GetDictionaryStats :many
+SELECT language, status, level, COUNT(*) AS entries
+FROM entry
+WHERE deleted_at IS NULL
+GROUP BY language, status, level
+ORDER BY language, status, level;
+
+-- name: AddEntryHistory :exec
+INSERT INTO entry_history(
+       language, entry, action, old_status, new_status, updated_by, created
+) VALUES (
+       @language, @entry, @action, @old_status, @new_status, @updated_by, now()
+);
+
+-- name: GetEntryHistory :many
+SELECT id, language, entry, action, old_status, new_status, updated_by, created
+FROM entry_history
+WHERE language = @language AND entry = @entry
+ORDER BY id;
+
+-- name: FindSimilarEntries :many
+SELECT language, entry, status, description, common_mistakes,
+       updated_at, updated_by, deleted_at, match_mode, level, forms,
+       enforced, affix_model,
+       similarity(entry, @text::text) AS similarity
+FROM entry
+WHERE language = @language
+      AND deleted_at IS NULL
+      AND entry % @text::text
+      AND similarity(entry, @text::text) >= @threshold::real
+ORDER BY similarity DESC, entry
+LIMIT sqlc.arg('limit')::bigint;
+
+-- name: DeleteEntriesByPrefix :many
+UPDATE entry
+SET deleted_at = now(), updated_at = now(), updated_by = @updated_by
+WHERE language = @language AND entry LIKE @pattern AND deleted_at IS NULL
+RETURNING entry, status;
+
+-- name: AddSpellingGaps :exec
+INSERT INTO spelling_gap(language, word, occurrences, first_seen, last_seen)
+SELECT @language::text, g.word, g.occurrences, now(), now()
+FROM unnest(@words::text[], @occurrences::bigint[]) AS g(word, occurrences)
+ON CONFLICT(language, word) DO
+  UPDATE SET
+       occurrences = spelling_gap.occurrences + excluded.occurrences,
+       last_seen = now();
+
+-- name: ListSpellingGaps :many
+SELECT g.language, g.word, g.occurrences, g.first_seen, g.last_seen
+FROM spelling_gap AS g
+WHERE
+        (sqlc.narg('language')::text IS NULL OR g.language = @language)
+        AND NOT EXISTS (
+            SELECT FROM entry AS e
+            WHERE e.language = g.language AND e.entry = g.word
+                  AND e.deleted_at IS NULL)
+ORDER BY g.occurrences DESC, g.language, g.word
+LIMIT sqlc.arg('limit')::bigint;
+
+-- name: ListChangedEntries :many
+SELECT language, entry, status, updated_at, deleted_at IS NOT NULL AS deleted
+FROM entry
+WHERE
+        (sqlc.narg('language')::text IS NULL OR language = @language)
+        AND updated_at >= @since
+ORDER BY language, entry;
+
+-- name: GetLastUpdated :many
+SELECT language, MAX(updated_at)::timestamptz AS last_updated
+FROM entry
+GROUP BY language;
@@ -11,25 +11,318 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addEntryHistory = `-- name: AddEntryHistory :exec
+INSERT INTO entry_history(
+       language, entry, action, old_status, new_status, updated_by, created
+) VALUES (
+       $1, $2, $3, $4, $5, $6, now()
+)
+`
+
+type AddEntryHistoryParams struct {
+	Language  string
+	Entry     string
+	Action    string
+	OldStatus pgtype.Text
+	NewStatus pgtype.Text
+	UpdatedBy string
+}
+
+func (q *Queries) AddEntryHistory(ctx context.Context, arg AddEntryHistoryParams) error {
+	_, err := q.db.Exec(ctx, addEntryHistory,
+		arg.Language,
+		arg.Entry,
+		arg.Action,
+		arg.OldStatus,
+		arg.NewStatus,
+		arg.UpdatedBy,
+	)
+	return err
+}
+
+const addSpellingGaps = `-- name: AddSpellingGaps :exec
+INSERT INTO spelling_gap(language, word, occurrences, first_seen, last_seen)
+SELECT $1::text, g.word, g.occurrences, now(), now()
+FROM unnest($2::text[], $3::bigint[]) AS g(word, occurrences)
+ON CONFLICT(language, word) DO
+  UPDATE SET
+       occurrences = spelling_gap.occurrences + excluded.occurrences,
+       last_seen = now()
+`
+
+type AddSpellingGapsParams struct {
+	Language    string
+	Words       []string
+	Occurrences []int64
+}
+
+func (q *Queries) AddSpellingGaps(ctx context.Context, arg AddSpellingGapsParams) error {
+	_, err := q.db.Exec(ctx, addSpellingGaps, arg.Language, arg.Words, arg.Occurrences)
+	return err
+}
+
+const countEntries = `-- name: CountEntries :one
+SELECT COUNT(*)
+FROM entry
+WHERE
+        ($1::text IS NULL OR language = $1)
+        AND ($2::text IS NULL OR entry LIKE $2)
+        AND ($3::text IS NULL OR status = $3)
+        AND ($4::text IS NULL OR EXISTS (
+            SELECT FROM unnest(common_mistakes) AS m WHERE m LIKE $4))
+        AND ($5::bool OR deleted_at IS NULL)
+`
+
+type CountEntriesParams struct {
+	Language       pgtype.Text
+	Pattern        pgtype.Text
+	Status         pgtype.Text
+	Mistake        pgtype.Text
+	IncludeDeleted bool
+}
+
+func (q *Queries) CountEntries(ctx context.Context, arg CountEntriesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countEntries,
+		arg.Language,
+		arg.Pattern,
+		arg.Status,
+		arg.Mistake,
+		arg.IncludeDeleted,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteEntriesByPrefix = `-- name: DeleteEntriesByPrefix :many
+UPDATE entry
+SET deleted_at = now(), updated_at = now(), updated_by = $1
+WHERE language = $2 AND entry LIKE $3 AND deleted_at IS NULL
+RETURNING entry, status
+`
+
+type DeleteEntriesByPrefixParams struct {
+	UpdatedBy string
+	Language  string
+	Pattern   string
+}
+
+type DeleteEntriesByPrefixRow struct {
+	Entry  string
+	Status string
+}
+
+func (q *Queries) DeleteEntriesByPrefix(ctx context.Context, arg DeleteEntriesByPrefixParams) ([]DeleteEntriesByPrefixRow, error) {
+	rows, err := q.db.Query(ctx, deleteEntriesByPrefix, arg.UpdatedBy, arg.Language, arg.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeleteEntriesByPrefixRow
+	for rows.Next() {
+		var i DeleteEntriesByPrefixRow
+		if err := rows.Scan(&i.Entry, &i.Status); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const deleteEntry = `-- name: DeleteEntry :exec
-DELETE FROM entry
-WHERE language = $1 AND entry = $2
+UPDATE entry
+SET deleted_at = now(), updated_at = now(), updated_by = $1
+WHERE language = $2 AND entry = $3 AND deleted_at IS NULL
 `
 
 type DeleteEntryParams struct {
-	Language string
-	Entry    string
+	UpdatedBy string
+	Language  string
+	Entry     string
 }
 
 func (q *Queries) DeleteEntry(ctx context.Context, arg DeleteEntryParams) error {
-	_, err := q.db.Exec(ctx, deleteEntry, arg.Language, arg.Entry)
+	_, err := q.db.Exec(ctx, deleteEntry, arg.UpdatedBy, arg.Language, arg.Entry)
 	return err
 }
 
+const findSimilarEntries = `-- name: FindSimilarEntries :many
+SELECT language, entry, status, description, common_mistakes,
+       updated_at, updated_by, deleted_at, match_mode, level, forms,
+       enforced, affix_model,
+       similarity(entry, $1::text) AS similarity
+FROM entry
+WHERE language = $2
+      AND deleted_at IS NULL
+      AND entry % $1::text
+      AND similarity(entry, $1::text) >= $3::real
+ORDER BY similarity DESC, entry
+LIMIT $4::bigint
+`
+
+type FindSimilarEntriesParams struct {
+	Text      string
+	Language  string
+	Threshold float32
+	Limit     int64
+}
+
+type FindSimilarEntriesRow struct {
+	Language       string
+	Entry          string
+	Status         string
+	Description    string
+	CommonMistakes []string
+	UpdatedAt      pgtype.Timestamptz
+	UpdatedBy      string
+	DeletedAt      pgtype.Timestamptz
+	MatchMode      string
+	Level          string
+	Forms          []string
+	Enforced       bool
+	AffixModel     string
+	Similarity     float32
+}
+
+func (q *Queries) FindSimilarEntries(ctx context.Context, arg FindSimilarEntriesParams) ([]FindSimilarEntriesRow, error) {
+	rows, err := q.db.Query(ctx, findSimilarEntries,
+		arg.Text,
+		arg.Language,
+		arg.Threshold,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindSimilarEntriesRow
+	for rows.Next() {
+		var i FindSimilarEntriesRow
+		if err := rows.Scan(
+			&i.Language,
+			&i.Entry,
+			&i.Status,
+			&i.Description,
+			&i.CommonMistakes,
+			&i.UpdatedAt,
+			&i.UpdatedBy,
+			&i.DeletedAt,
+			&i.MatchMode,
+			&i.Level,
+			&i.Forms,
+			&i.Enforced,
+			&i.AffixModel,
+			&i.Similarity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDictionaryStats = `-- name: GetDictionaryStats :many
+SELECT language, status, level, COUNT(*) AS entries
+FROM entry
+WHERE deleted_at IS NULL
+GROUP BY language, status, level
+ORDER BY language, status, level
+`
+
+type GetDictionaryStatsRow struct {
+	Language string
+	Status   string
+	Level    string
+	Entries  int64
+}
+
+func (q *Queries) GetDictionaryStats(ctx context.Context) ([]GetDictionaryStatsRow, error) {
+	rows, err := q.db.Query(ctx, getDictionaryStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDictionaryStatsRow
+	for rows.Next() {
+		var i GetDictionaryStatsRow
+		if err := rows.Scan(
+			&i.Language,
+			&i.Status,
+			&i.Level,
+			&i.Entries,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEntries = `-- name: GetEntries :many
+SELECT e.language, e.entry, e.status, e.description, e.common_mistakes,
+       e.updated_at, e.updated_by, e.deleted_at, e.match_mode, e.level, e.forms,
+       e.enforced, e.affix_model
+FROM entry AS e
+     INNER JOIN unnest($1::text[], $2::text[]) AS k(language, entry)
+           ON e.language = k.language AND e.entry = k.entry
+WHERE $3::bool OR e.deleted_at IS NULL
+`
+
+type GetEntriesParams struct {
+	Languages      []string
+	Entries        []string
+	IncludeDeleted bool
+}
+
+func (q *Queries) GetEntries(ctx context.Context, arg GetEntriesParams) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, getEntries, arg.Languages, arg.Entries, arg.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Entry
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.Language,
+			&i.Entry,
+			&i.Status,
+			&i.Description,
+			&i.CommonMistakes,
+			&i.UpdatedAt,
+			&i.UpdatedBy,
+			&i.DeletedAt,
+			&i.MatchMode,
+			&i.Level,
+			&i.Forms,
+			&i.Enforced,
+			&i.AffixModel,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getEntry = `-- name: GetEntry :one
-SELECT language, entry, status, description, common_mistakes
+SELECT language, entry, status, description, common_mistakes,
+       updated_at, updated_by, deleted_at, match_mode, level, forms,
+       enforced, affix_model
 FROM entry
-WHERE language = $1 AND entry = $2
+WHERE language = $1 AND entry = $2 AND deleted_at IS NULL
 `
 
 type GetEntryParams struct {
@@ -46,13 +339,248 @@ func (q *Queries) GetEntry(ctx context.Context, arg GetEntryParams) (Entry, erro
 		&i.Status,
 		&i.Description,
 		&i.CommonMistakes,
+		&i.UpdatedAt,
+		&i.UpdatedBy,
+		&i.DeletedAt,
+		&i.MatchMode,
+		&i.Level,
+		&i.Forms,
+		&i.Enforced,
+		&i.AffixModel,
+	)
+	return i, err
+}
+
+const getEntryForUpdate = `-- name: GetEntryForUpdate :one
+SELECT language, entry, status, description, common_mistakes,
+       updated_at, updated_by, deleted_at, match_mode, level, forms,
+       enforced, affix_model
+FROM entry
+WHERE language = $1 AND entry = $2
+FOR UPDATE
+`
+
+type GetEntryForUpdateParams struct {
+	Language string
+	Entry    string
+}
+
+func (q *Queries) GetEntryForUpdate(ctx context.Context, arg GetEntryForUpdateParams) (Entry, error) {
+	row := q.db.QueryRow(ctx, getEntryForUpdate, arg.Language, arg.Entry)
+	var i Entry
+	err := row.Scan(
+		&i.Language,
+		&i.Entry,
+		&i.Status,
+		&i.Description,
+		&i.CommonMistakes,
+		&i.UpdatedAt,
+		&i.UpdatedBy,
+		&i.DeletedAt,
+		&i.MatchMode,
+		&i.Level,
+		&i.Forms,
+		&i.Enforced,
+		&i.AffixModel,
 	)
 	return i, err
 }
 
+const getEntryHistory = `-- name: GetEntryHistory :many
+SELECT id, language, entry, action, old_status, new_status, updated_by, created
+FROM entry_history
+WHERE language = $1 AND entry = $2
+ORDER BY id
+`
+
+type GetEntryHistoryParams struct {
+	Language string
+	Entry    string
+}
+
+func (q *Queries) GetEntryHistory(ctx context.Context, arg GetEntryHistoryParams) ([]EntryHistory, error) {
+	rows, err := q.db.Query(ctx, getEntryHistory, arg.Language, arg.Entry)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []EntryHistory
+	for rows.Next() {
+		var i EntryHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.Language,
+			&i.Entry,
+			&i.Action,
+			&i.OldStatus,
+			&i.NewStatus,
+			&i.UpdatedBy,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLastUpdated = `-- name: GetLastUpdated :many
+SELECT language, MAX(updated_at)::timestamptz AS last_updated
+FROM entry
+GROUP BY language
+`
+
+type GetLastUpdatedRow struct {
+	Language    string
+	LastUpdated pgtype.Timestamptz
+}
+
+func (q *Queries) GetLastUpdated(ctx context.Context) ([]GetLastUpdatedRow, error) {
+	rows, err := q.db.Query(ctx, getLastUpdated)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLastUpdatedRow
+	for rows.Next() {
+		var i GetLastUpdatedRow
+		if err := rows.Scan(&i.Language, &i.LastUpdated); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const iterateEntries = `-- name: IterateEntries :many
+SELECT language, entry, status, description, common_mistakes,
+       updated_at, updated_by, deleted_at, match_mode, level, forms,
+       enforced, affix_model
+FROM entry
+WHERE
+        ($1::text IS NULL OR language = $1)
+        AND ($2::text IS NULL OR entry LIKE $2)
+        AND ($3::text IS NULL OR status = $3)
+        AND ($4::text IS NULL OR EXISTS (
+            SELECT FROM unnest(common_mistakes) AS m WHERE m LIKE $4))
+        AND ($5::bool OR deleted_at IS NULL)
+        AND (language, entry) > ($6::text, $7::text)
+ORDER BY language, entry
+LIMIT $8::bigint
+`
+
+type IterateEntriesParams struct {
+	Language       pgtype.Text
+	Pattern        pgtype.Text
+	Status         pgtype.Text
+	Mistake        pgtype.Text
+	IncludeDeleted bool
+	AfterLanguage  string
+	AfterEntry     string
+	Limit          int64
+}
+
+func (q *Queries) IterateEntries(ctx context.Context, arg IterateEntriesParams) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, iterateEntries,
+		arg.Language,
+		arg.Pattern,
+		arg.Status,
+		arg.Mistake,
+		arg.IncludeDeleted,
+		arg.AfterLanguage,
+		arg.AfterEntry,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Entry
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.Language,
+			&i.Entry,
+			&i.Status,
+			&i.Description,
+			&i.CommonMistakes,
+			&i.UpdatedAt,
+			&i.UpdatedBy,
+			&i.DeletedAt,
+			&i.MatchMode,
+			&i.Level,
+			&i.Forms,
+			&i.Enforced,
+			&i.AffixModel,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChangedEntries = `-- name: ListChangedEntries :many
+SELECT language, entry, status, updated_at, deleted_at IS NOT NULL AS deleted
+FROM entry
+WHERE
+        ($1::text IS NULL OR language = $1)
+        AND updated_at >= $2
+ORDER BY language, entry
+`
+
+type ListChangedEntriesParams struct {
+	Language pgtype.Text
+	Since    pgtype.Timestamptz
+}
+
+type ListChangedEntriesRow struct {
+	Language  string
+	Entry     string
+	Status    string
+	UpdatedAt pgtype.Timestamptz
+	Deleted   bool
+}
+
+func (q *Queries) ListChangedEntries(ctx context.Context, arg ListChangedEntriesParams) ([]ListChangedEntriesRow, error) {
+	rows, err := q.db.Query(ctx, listChangedEntries, arg.Language, arg.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListChangedEntriesRow
+	for rows.Next() {
+		var i ListChangedEntriesRow
+		if err := rows.Scan(
+			&i.Language,
+			&i.Entry,
+			&i.Status,
+			&i.UpdatedAt,
+			&i.Deleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listDictionaries = `-- name: ListDictionaries :many
 SELECT language, COUNT(*) AS entries
 FROM entry
+WHERE deleted_at IS NULL
 GROUP BY language
 `
 
@@ -82,12 +610,16 @@ func (q *Queries) ListDictionaries(ctx context.Context) ([]ListDictionariesRow,
 }
 
 const listEntries = `-- name: ListEntries :many
-SELECT language, entry, status, description, common_mistakes
+SELECT language, entry, status, description, common_mistakes,
+       updated_at, updated_by, deleted_at, match_mode, level, forms,
+       enforced, affix_model
 FROM entry
 WHERE
         ($1::text IS NULL OR language = $1)
         AND ($2::text IS NULL OR entry LIKE $2)
         AND ($3::text IS NULL OR status = $3)
+        AND deleted_at IS NULL
+ORDER BY language, entry
 LIMIT $5::bigint OFFSET $4::bigint
 `
 
@@ -120,6 +652,58 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.Status,
 			&i.Description,
 			&i.CommonMistakes,
+			&i.UpdatedAt,
+			&i.UpdatedBy,
+			&i.DeletedAt,
+			&i.MatchMode,
+			&i.Level,
+			&i.Forms,
+			&i.Enforced,
+			&i.AffixModel,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSpellingGaps = `-- name: ListSpellingGaps :many
+SELECT g.language, g.word, g.occurrences, g.first_seen, g.last_seen
+FROM spelling_gap AS g
+WHERE
+        ($1::text IS NULL OR g.language = $1)
+        AND NOT EXISTS (
+            SELECT FROM entry AS e
+            WHERE e.language = g.language AND e.entry = g.word
+                  AND e.deleted_at IS NULL)
+ORDER BY g.occurrences DESC, g.language, g.word
+LIMIT $2::bigint
+`
+
+type ListSpellingGapsParams struct {
+	Language pgtype.Text
+	Limit    int64
+}
+
+func (q *Queries) ListSpellingGaps(ctx context.Context, arg ListSpellingGapsParams) ([]SpellingGap, error) {
+	rows, err := q.db.Query(ctx, listSpellingGaps, arg.Language, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SpellingGap
+	for rows.Next() {
+		var i SpellingGap
+		if err := rows.Scan(
+			&i.Language,
+			&i.Word,
+			&i.Occurrences,
+			&i.FirstSeen,
+			&i.LastSeen,
 		); err != nil {
 			return nil, err
 		}
@@ -145,16 +729,52 @@ func (q *Queries) Notify(ctx context.Context, arg NotifyParams) error {
 	return err
 }
 
+const restoreEntry = `-- name: RestoreEntry :execrows
+UPDATE entry
+SET deleted_at = NULL, updated_at = now(), updated_by = $1
+WHERE language = $2 AND entry = $3 AND deleted_at IS NOT NULL
+`
+
+type RestoreEntryParams struct {
+	UpdatedBy string
+	Language  string
+	Entry     string
+}
+
+func (q *Queries) RestoreEntry(ctx context.Context, arg RestoreEntryParams) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreEntry, arg.UpdatedBy, arg.Language, arg.Entry)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const setEntry = `-- name: SetEntry :exec
 INSERT INTO entry(
-       language, entry, status, description, common_mistakes
+       language, entry, status, description, common_mistakes,
+       updated_at, updated_by, match_mode, level, forms,
+       enforced, affix_model
 ) VALUES (
-       $1, $2, $3, $4, $5
+       $1, $2, $3, $4, $5,
+       now(), $6,
+       COALESCE($7::text, 'sequence'),
+       COALESCE($8::text, 'auto'),
+       COALESCE($9::text[], '{}'),
+       COALESCE($10::bool, false),
+       COALESCE($11::text, '')
 ) ON CONFLICT(language, entry) DO
   UPDATE SET
        status = $3,
        description = $4,
-       common_mistakes = $5
+       common_mistakes = $5,
+       updated_at = now(),
+       updated_by = $6,
+       deleted_at = NULL,
+       match_mode = COALESCE($7::text, entry.match_mode),
+       level = COALESCE($8::text, entry.level),
+       forms = COALESCE($9::text[], entry.forms),
+       enforced = COALESCE($10::bool, entry.enforced),
+       affix_model = COALESCE($11::text, entry.affix_model)
 `
 
 type SetEntryParams struct {
@@ -163,6 +783,12 @@ type SetEntryParams struct {
 	Status         string
 	Description    string
 	CommonMistakes []string
+	UpdatedBy      string
+	MatchMode      pgtype.Text
+	Level          pgtype.Text
+	Forms          []string
+	Enforced       pgtype.Bool
+	AffixModel     pgtype.Text
 }
 
 func (q *Queries) SetEntry(ctx context.Context, arg SetEntryParams) error {
@@ -172,6 +798,12 @@ func (q *Queries) SetEntry(ctx context.Context, arg SetEntryParams) error {
 		arg.Status,
 		arg.Description,
 		arg.CommonMistakes,
+		arg.UpdatedBy,
+		arg.MatchMode,
+		arg.Level,
+		arg.Forms,
+		arg.Enforced,
+		arg.AffixModel,
 	)
 	return err
 }
@@ -16,6 +16,20 @@ SET xmloption = content;
 SET client_min_messages = warning;
 SET row_security = off;
 
+--
+-- Name: pg_trgm; Type: EXTENSION; Schema: -; Owner: -
+--
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public;
+
+
+--
+-- Name: EXTENSION pg_trgm; Type: COMMENT; Schema: -; Owner: -
+--
+
+COMMENT ON EXTENSION pg_trgm IS 'text similarity measurement and index searching based on trigrams';
+
+
 SET default_tablespace = '';
 
 SET default_table_access_method = heap;
@@ -29,7 +43,45 @@ CREATE TABLE public.entry (
     entry text NOT NULL,
     status text NOT NULL,
     description text NOT NULL,
-    common_mistakes text[]
+    common_mistakes text[],
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_by text DEFAULT ''::text NOT NULL,
+    deleted_at timestamp with time zone,
+    match_mode text DEFAULT 'sequence'::text NOT NULL,
+    level text DEFAULT 'auto'::text NOT NULL,
+    forms text[] DEFAULT '{}'::text[] NOT NULL,
+    enforced boolean DEFAULT false NOT NULL,
+    affix_model text DEFAULT ''::text NOT NULL
+);
+
+
+--
+-- Name: entry_history; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.entry_history (
+    id bigint NOT NULL,
+    language text NOT NULL,
+    entry text NOT NULL,
+    action text NOT NULL,
+    old_status text,
+    new_status text,
+    updated_by text NOT NULL,
+    created timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+--
+-- Name: entry_history_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+ALTER TABLE public.entry_history ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME public.entry_history_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
 );
 
 
@@ -42,6 +94,19 @@ CREATE TABLE public.schema_version (
 );
 
 
+--
+-- Name: spelling_gap; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.spelling_gap (
+    language text NOT NULL,
+    word text NOT NULL,
+    occurrences bigint NOT NULL,
+    first_seen timestamp with time zone NOT NULL,
+    last_seen timestamp with time zone NOT NULL
+);
+
+
 --
 -- Name: entry entry_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
@@ -50,11 +115,55 @@ ALTER TABLE ONLY public.entry
     ADD CONSTRAINT entry_pkey PRIMARY KEY (language, entry);
 
 
+--
+-- Name: entry_history entry_history_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.entry_history
+    ADD CONSTRAINT entry_history_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: spelling_gap spelling_gap_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.spelling_gap
+    ADD CONSTRAINT spelling_gap_pkey PRIMARY KEY (language, word);
+
+
+--
+-- Name: idx_entry_history_entry; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX idx_entry_history_entry ON public.entry_history USING btree (language, entry, id);
+
+
+--
+-- Name: idx_entry_language_pattern_ops; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX idx_entry_language_pattern_ops ON public.entry USING btree (language, entry text_pattern_ops);
+
+
 --
 -- Name: idx_entry_pattern_ops; Type: INDEX; Schema: public; Owner: -
 --
 
-CREATE INDEX idx_entry_pattern_ops ON public.entry USING btree (entry varchar_pattern_ops);
+CREATE INDEX idx_entry_pattern_ops ON public.entry USING btree (entry text_pattern_ops);
+
+
+--
+-- Name: idx_entry_trgm; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX idx_entry_trgm ON public.entry USING gin (entry public.gin_trgm_ops);
+
+
+--
+-- Name: idx_spelling_gap_occurrences; Type: INDEX; Schema: public; Owner: -
+--
+
+CREATE INDEX idx_spelling_gap_occurrences ON public.spelling_gap USING btree (language, occurrences DESC);
 
 
 --
@@ -0,0 +1,9 @@
+ALTER TABLE entry
+      ADD COLUMN updated_at timestamptz not null default now(),
+      ADD COLUMN updated_by text not null default '';
+
+---- create above / drop below ----
+
+ALTER TABLE entry
+      DROP COLUMN IF EXISTS updated_at,
+      DROP COLUMN IF EXISTS updated_by;
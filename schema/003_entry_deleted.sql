@@ -0,0 +1,9 @@
+ALTER TABLE entry
+      ADD COLUMN deleted_at timestamptz;
+
+---- create above / drop below ----
+
+DELETE FROM entry WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE entry
+      DROP COLUMN IF EXISTS deleted_at;
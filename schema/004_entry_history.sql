@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS entry_history(
+       id bigint generated always as identity primary key,
+       language text not null,
+       entry text not null,
+       action text not null,
+       old_status text,
+       new_status text,
+       updated_by text not null,
+       created timestamptz not null default now()
+);
+
+CREATE INDEX idx_entry_history_entry ON entry_history (language, entry, id);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS entry_history;
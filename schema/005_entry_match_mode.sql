@@ -0,0 +1,7 @@
+ALTER TABLE entry
+      ADD COLUMN match_mode text NOT NULL DEFAULT 'sequence';
+
+---- create above / drop below ----
+
+ALTER TABLE entry
+      DROP COLUMN IF EXISTS match_mode;
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_entry_pattern_ops;
+
+CREATE INDEX idx_entry_pattern_ops ON entry (entry text_pattern_ops);
+
+CREATE INDEX idx_entry_language_pattern_ops
+       ON entry (language, entry text_pattern_ops);
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS idx_entry_language_pattern_ops;
+DROP INDEX IF EXISTS idx_entry_pattern_ops;
+
+CREATE INDEX idx_entry_pattern_ops ON entry (entry varchar_pattern_ops);
@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_entry_trgm ON entry USING gin (entry gin_trgm_ops);
+
+---- create above / drop below ----
+
+DROP INDEX IF EXISTS idx_entry_trgm;
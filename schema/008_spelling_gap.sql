@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS spelling_gap(
+       language text not null,
+       word text not null,
+       occurrences bigint not null,
+       first_seen timestamptz not null,
+       last_seen timestamptz not null,
+       primary key(language, word)
+);
+
+CREATE INDEX idx_spelling_gap_occurrences
+       ON spelling_gap (language, occurrences DESC);
+
+---- create above / drop below ----
+
+DROP TABLE IF EXISTS spelling_gap;
@@ -0,0 +1,7 @@
+ALTER TABLE entry
+      ADD COLUMN level text NOT NULL DEFAULT 'auto';
+
+---- create above / drop below ----
+
+ALTER TABLE entry
+      DROP COLUMN IF EXISTS level;
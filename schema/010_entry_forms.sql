@@ -0,0 +1,7 @@
+ALTER TABLE entry
+      ADD COLUMN forms text[] NOT NULL DEFAULT '{}';
+
+---- create above / drop below ----
+
+ALTER TABLE entry
+      DROP COLUMN IF EXISTS forms;
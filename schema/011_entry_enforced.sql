@@ -0,0 +1,7 @@
+ALTER TABLE entry
+      ADD COLUMN enforced boolean NOT NULL DEFAULT false;
+
+---- create above / drop below ----
+
+ALTER TABLE entry
+      DROP COLUMN IF EXISTS enforced;